@@ -0,0 +1,222 @@
+package clippoly
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IntersectAll intersects every polygon of layer a with every polygon of
+// layer b and returns all pieces. Pairs whose bounding boxes do not overlap
+// are skipped. Rings are oriented as in Union; pieces only overlap where a
+// layer overlaps itself.
+func IntersectAll(a, b Polygons) (Polygons, error) {
+	return Boolean{}.IntersectAll(a, b)
+}
+
+// IntersectAll is IntersectAll with the backend of o.
+func (o Boolean) IntersectAll(a, b Polygons) (Polygons, error) {
+	if err := validateLayer("layer a", a); err != nil {
+		return nil, err
+	}
+	if err := validateLayer("layer b", b); err != nil {
+		return nil, err
+	}
+
+	boxesB := layerBounds(b)
+
+	var result Polygons
+	for _, pa := range a {
+		ba := polygonBounds(pa)
+		for j, pb := range b {
+			if !ba.overlaps(boxesB[j]) {
+				continue
+			}
+			rings, err := o.overlay(Polygons{pa}, Polygons{pb}, opIntersection, EvenOdd)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, rings...)
+		}
+	}
+
+	return result, nil
+}
+
+// UnionAll merges both layers into the area they cover together. Only
+// polygons whose bounding boxes touch, directly or through a chain of other
+// polygons, are merged with each other.
+func UnionAll(a, b Polygons) (Polygons, error) {
+	return Boolean{}.UnionAll(a, b)
+}
+
+// UnionAll is UnionAll with the backend of o.
+func (o Boolean) UnionAll(a, b Polygons) (Polygons, error) {
+	if err := validateLayer("layer a", a); err != nil {
+		return nil, err
+	}
+	if err := validateLayer("layer b", b); err != nil {
+		return nil, err
+	}
+
+	all := make(Polygons, 0, len(a)+len(b))
+	all = append(all, a...)
+	all = append(all, b...)
+
+	var result Polygons
+	for _, group := range clusterByBounds(all) {
+		if len(group) == 1 {
+			result = append(result, orientRing(group[0], true))
+			continue
+		}
+		merged := Polygons{group[0]}
+		for _, p := range group[1:] {
+			var err error
+			if merged, err = o.overlay(merged, Polygons{p}, opUnion, EvenOdd); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, merged...)
+	}
+
+	return result, nil
+}
+
+// DifferenceAll cuts the polygons of layer b out of every polygon of layer a.
+// Each polygon of a is only cut by the polygons of b whose bounding boxes
+// overlap it.
+func DifferenceAll(a, b Polygons) (Polygons, error) {
+	return Boolean{}.DifferenceAll(a, b)
+}
+
+// DifferenceAll is DifferenceAll with the backend of o.
+func (o Boolean) DifferenceAll(a, b Polygons) (Polygons, error) {
+	if err := validateLayer("layer a", a); err != nil {
+		return nil, err
+	}
+	if err := validateLayer("layer b", b); err != nil {
+		return nil, err
+	}
+
+	return o.differenceAll(a, b)
+}
+
+// XorAll returns the area covered by exactly one of the layers, as the pieces
+// of a outside b followed by the pieces of b outside a.
+func XorAll(a, b Polygons) (Polygons, error) {
+	return Boolean{}.XorAll(a, b)
+}
+
+// XorAll is XorAll with the backend of o.
+func (o Boolean) XorAll(a, b Polygons) (Polygons, error) {
+	if err := validateLayer("layer a", a); err != nil {
+		return nil, err
+	}
+	if err := validateLayer("layer b", b); err != nil {
+		return nil, err
+	}
+
+	aOnly, err := o.differenceAll(a, b)
+	if err != nil {
+		return nil, err
+	}
+	bOnly, err := o.differenceAll(b, a)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(aOnly, bOnly...), nil
+}
+
+func (o Boolean) differenceAll(a, b Polygons) (Polygons, error) {
+	boxesB := layerBounds(b)
+
+	var result Polygons
+	for _, pa := range a {
+		ba := polygonBounds(pa)
+		rings := Polygons{orientRing(pa, true)}
+		for j, pb := range b {
+			if !ba.overlaps(boxesB[j]) {
+				continue
+			}
+			var err error
+			if rings, err = o.overlay(rings, Polygons{pb}, opDifference, EvenOdd); err != nil {
+				return nil, err
+			}
+			if len(rings) == 0 {
+				break
+			}
+		}
+		result = append(result, rings...)
+	}
+
+	return result, nil
+}
+
+func validateLayer(name string, layer Polygons) error {
+	for i, p := range layer {
+		if err := validatePolygon(fmt.Sprintf("%s polygon %d", name, i), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func layerBounds(layer Polygons) []bounds {
+	boxes := make([]bounds, len(layer))
+	for i, p := range layer {
+		boxes[i] = polygonBounds(p)
+	}
+	return boxes
+}
+
+// clusterByBounds groups polygons whose bounding boxes touch, directly or
+// through other polygons of the group. Groups keep the input order.
+func clusterByBounds(polys Polygons) []Polygons {
+	boxes := layerBounds(polys)
+
+	order := make([]int, len(polys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return boxes[order[i]].minX < boxes[order[j]].minX
+	})
+
+	parent := make([]int, len(polys))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for k, i := range order {
+		for _, j := range order[k+1:] {
+			if boxes[j].minX > boxes[i].maxX {
+				break
+			}
+			if boxes[i].touches(boxes[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	var groups []Polygons
+	for i, p := range polys {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], p)
+	}
+
+	return groups
+}
@@ -0,0 +1,54 @@
+package clippoly
+
+import (
+	"math"
+	"testing"
+)
+
+func square(x, y, size float64) Polygon {
+	return Polygon{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+}
+
+func TestBatchOperations(t *testing.T) {
+	layerA := Polygons{square(0, 0, 2), square(2, 0, 2), square(10, 0, 2)}
+	layerB := Polygons{square(1, 1, 2), square(11, 0, 2), square(100, 100, 1)}
+
+	tests := []struct {
+		name  string
+		op    func(a, b Polygons) (Polygons, error)
+		rings int
+		area  float64
+	}{
+		{name: "intersect", op: IntersectAll, rings: 3, area: 1 + 1 + 2},
+		{name: "union", op: UnionAll, rings: 3, area: 10 + 6 + 1},
+		{name: "difference", op: DifferenceAll, rings: 3, area: 3 + 3 + 2},
+		{name: "xor", op: XorAll, rings: 6, area: 3 + 3 + 2 + 2 + 2 + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op(layerA, layerB)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if len(got) != tt.rings {
+				t.Fatalf("got %d rings, want %d: %v", len(got), tt.rings, got)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("area = %.3f, want %.3f", totalArea(got), tt.area)
+			}
+		})
+	}
+}
+
+func TestClusterByBounds(t *testing.T) {
+	polys := Polygons{square(0, 0, 1), square(5, 5, 1), square(1, 0, 1), square(2, 1, 1)}
+
+	groups := clusterByBounds(polys)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if len(groups[0]) != 3 || len(groups[1]) != 1 {
+		t.Fatalf("unexpected group sizes %d and %d", len(groups[0]), len(groups[1]))
+	}
+}
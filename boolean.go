@@ -0,0 +1,77 @@
+package clippoly
+
+import "context"
+
+// Boolean runs the boolean operations of this package with a chosen
+// backend. The package-level functions use the zero value.
+type Boolean struct {
+	// Backend selects the noding algorithm.
+	Backend Backend
+}
+
+// overlay runs the overlay with the tolerance derived from the inputs and
+// the backend of o
+func (o Boolean) overlay(target, clip Polygons, op boolOp, rule FillRule) (Polygons, error) {
+	if err := o.Backend.validate(); err != nil {
+		return nil, err
+	}
+	tol := scaledTolerance(target, clip)
+	tol.backend = o.Backend
+	return tol.overlay(context.Background(), target, clip, op, rule)
+}
+
+// Union returns the region covered by a, b or both. Unlike Clip the result is
+// not triangulated: it is a set of rings where outer boundaries run
+// counter-clockwise and holes clockwise.
+func Union(a, b Polygon) (Polygons, error) {
+	return Boolean{}.Union(a, b)
+}
+
+// Union is Union with the backend of o.
+func (o Boolean) Union(a, b Polygon) (Polygons, error) {
+	if err := validatePolygon("polygon a", a); err != nil {
+		return nil, err
+	}
+	if err := validatePolygon("polygon b", b); err != nil {
+		return nil, err
+	}
+
+	return o.overlay(Polygons{a}, Polygons{b}, opUnion, EvenOdd)
+}
+
+// Difference returns the part of target that is not covered by clip. A clip
+// lying completely inside target cuts a hole, and a clip that misses target
+// leaves it unchanged. Rings are oriented as in Union.
+func Difference(target, clip Polygon) (Polygons, error) {
+	return Boolean{}.Difference(target, clip)
+}
+
+// Difference is Difference with the backend of o.
+func (o Boolean) Difference(target, clip Polygon) (Polygons, error) {
+	if err := validatePolygon("target polygon", target); err != nil {
+		return nil, err
+	}
+	if err := validatePolygon("clip polygon", clip); err != nil {
+		return nil, err
+	}
+
+	return o.overlay(Polygons{target}, Polygons{clip}, opDifference, EvenOdd)
+}
+
+// Xor returns the regions covered by exactly one of a and b. Rings are
+// oriented as in Union.
+func Xor(a, b Polygon) (Polygons, error) {
+	return Boolean{}.Xor(a, b)
+}
+
+// Xor is Xor with the backend of o.
+func (o Boolean) Xor(a, b Polygon) (Polygons, error) {
+	if err := validatePolygon("polygon a", a); err != nil {
+		return nil, err
+	}
+	if err := validatePolygon("polygon b", b); err != nil {
+		return nil, err
+	}
+
+	return o.overlay(Polygons{a}, Polygons{b}, opXor, EvenOdd)
+}
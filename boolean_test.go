@@ -0,0 +1,426 @@
+package clippoly
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func totalArea(rings Polygons) float64 {
+	var sum float64
+	for _, r := range rings {
+		sum += signedArea(r)
+	}
+	return sum
+}
+
+func countHoles(rings Polygons) int {
+	holes := 0
+	for _, r := range rings {
+		if signedArea(r) < 0 {
+			holes++
+		}
+	}
+	return holes
+}
+
+func TestUnion(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     Polygon
+		b     Polygon
+		rings int
+		holes int
+		area  float64
+	}{
+		{
+			name:  "overlapping_squares",
+			a:     Polygon{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+			b:     Polygon{{1, 1}, {3, 1}, {3, 3}, {1, 3}},
+			rings: 1,
+			area:  7,
+		},
+		{
+			name:  "clockwise_input",
+			a:     Polygon{{0, 2}, {2, 2}, {2, 0}, {0, 0}},
+			b:     Polygon{{1, 1}, {3, 1}, {3, 3}, {1, 3}},
+			rings: 1,
+			area:  7,
+		},
+		{
+			name:  "disjoint",
+			a:     Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+			b:     Polygon{{5, 5}, {6, 5}, {6, 6}, {5, 6}},
+			rings: 2,
+			area:  2,
+		},
+		{
+			name:  "contained",
+			a:     Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+			b:     Polygon{{2, 2}, {4, 2}, {4, 4}},
+			rings: 1,
+			area:  100,
+		},
+		{
+			name:  "shared_edge",
+			a:     Polygon{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+			b:     Polygon{{2, 0}, {4, 0}, {4, 2}, {2, 2}},
+			rings: 1,
+			area:  8,
+		},
+		{
+			name:  "closes_courtyard",
+			a:     Polygon{{0, 0}, {6, 0}, {6, 6}, {0, 6}, {0, 4}, {4, 4}, {4, 2}, {0, 2}},
+			b:     Polygon{{-1, 1}, {2, 1}, {2, 5}, {-1, 5}},
+			rings: 2,
+			holes: 1,
+			area:  36,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Union(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("union: %v", err)
+			}
+			if len(got) != tt.rings {
+				t.Fatalf("got %d rings, want %d: %v", len(got), tt.rings, got)
+			}
+			if holes := countHoles(got); holes != tt.holes {
+				t.Fatalf("got %d holes, want %d", holes, tt.holes)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("union area = %.3f, want %.3f", totalArea(got), tt.area)
+			}
+
+			filename := filepath.Join("test_output", fmt.Sprintf("union_%s.png", tt.name))
+			if err := saveTriangleCropPNG(filename, tt.b, tt.a, got); err != nil {
+				t.Fatalf("save png: %v", err)
+			}
+		})
+	}
+}
+
+func TestDifference(t *testing.T) {
+	square := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+
+	tests := []struct {
+		name  string
+		clip  Polygon
+		rings int
+		holes int
+		area  float64
+	}{
+		{
+			name:  "partial_overlap",
+			clip:  Polygon{{2, 2}, {6, 2}, {6, 6}, {2, 6}},
+			rings: 1,
+			area:  12,
+		},
+		{
+			name:  "clip_inside_makes_hole",
+			clip:  Polygon{{1, 1}, {3, 1}, {3, 3}, {1, 3}},
+			rings: 2,
+			holes: 1,
+			area:  12,
+		},
+		{
+			name:  "disjoint_keeps_target",
+			clip:  Polygon{{10, 10}, {12, 10}, {12, 12}},
+			rings: 1,
+			area:  16,
+		},
+		{
+			name:  "target_inside_clip",
+			clip:  Polygon{{-1, -1}, {5, -1}, {5, 5}, {-1, 5}},
+			rings: 0,
+			area:  0,
+		},
+		{
+			name:  "strip_splits_target",
+			clip:  Polygon{{1, -1}, {3, -1}, {3, 5}, {1, 5}},
+			rings: 2,
+			area:  8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Difference(square, tt.clip)
+			if err != nil {
+				t.Fatalf("difference: %v", err)
+			}
+			if len(got) != tt.rings {
+				t.Fatalf("got %d rings, want %d: %v", len(got), tt.rings, got)
+			}
+			if holes := countHoles(got); holes != tt.holes {
+				t.Fatalf("got %d holes, want %d", holes, tt.holes)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("difference area = %.3f, want %.3f", totalArea(got), tt.area)
+			}
+
+			filename := filepath.Join("test_output", fmt.Sprintf("difference_%s.png", tt.name))
+			if err := saveTriangleCropPNG(filename, tt.clip, square, got); err != nil {
+				t.Fatalf("save png: %v", err)
+			}
+		})
+	}
+}
+
+func TestXor(t *testing.T) {
+	square := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+
+	tests := []struct {
+		name  string
+		b     Polygon
+		rings int
+		holes int
+		area  float64
+	}{
+		{
+			name:  "overlapping_squares",
+			b:     Polygon{{2, 2}, {6, 2}, {6, 6}, {2, 6}},
+			rings: 2,
+			area:  24,
+		},
+		{
+			name:  "contained",
+			b:     Polygon{{1, 1}, {3, 1}, {3, 3}, {1, 3}},
+			rings: 2,
+			holes: 1,
+			area:  12,
+		},
+		{
+			name:  "identical",
+			b:     Polygon{{0, 4}, {0, 0}, {4, 0}, {4, 4}},
+			rings: 0,
+			area:  0,
+		},
+		{
+			name:  "disjoint",
+			b:     Polygon{{10, 0}, {11, 0}, {11, 1}},
+			rings: 2,
+			area:  16.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Xor(square, tt.b)
+			if err != nil {
+				t.Fatalf("xor: %v", err)
+			}
+			if len(got) != tt.rings {
+				t.Fatalf("got %d rings, want %d: %v", len(got), tt.rings, got)
+			}
+			if holes := countHoles(got); holes != tt.holes {
+				t.Fatalf("got %d holes, want %d", holes, tt.holes)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("xor area = %.3f, want %.3f", totalArea(got), tt.area)
+			}
+		})
+	}
+}
+
+func TestSharedEdges(t *testing.T) {
+	a := square(0, 0, 2)
+
+	tests := []struct {
+		name  string
+		b     Polygon
+		union float64
+		diff  float64
+		clip  float64
+		rings int // rings of the union
+	}{
+		{name: "full_edge", b: square(2, 0, 2), union: 8, diff: 4, clip: 0, rings: 1},
+		{name: "partial_edge", b: square(2, 1, 2), union: 8, diff: 4, clip: 0, rings: 1},
+		{name: "edge_inside_edge", b: Polygon{{2, 0.5}, {3, 0.5}, {3, 1.5}, {2, 1.5}}, union: 5, diff: 4, clip: 0, rings: 1},
+		{name: "overlap_along_edge", b: Polygon{{-1, 0}, {3, 0}, {3, 1}, {-1, 1}}, union: 6, diff: 2, clip: 2, rings: 1},
+		{name: "corner_square", b: square(0, 0, 1), union: 4, diff: 3, clip: 1, rings: 1},
+		{name: "strip_between_edges", b: Polygon{{0, 0.5}, {2, 0.5}, {2, 1.5}, {0, 1.5}}, union: 4, diff: 2, clip: 2, rings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			union, err := Union(a, tt.b)
+			if err != nil {
+				t.Fatalf("union: %v", err)
+			}
+			if len(union) != tt.rings {
+				t.Fatalf("union has %d rings, want %d: %v", len(union), tt.rings, union)
+			}
+			if math.Abs(totalArea(union)-tt.union) > 1e-9 {
+				t.Fatalf("union area = %.3f, want %.3f", totalArea(union), tt.union)
+			}
+
+			diff, err := Difference(a, tt.b)
+			if err != nil {
+				t.Fatalf("difference: %v", err)
+			}
+			if math.Abs(totalArea(diff)-tt.diff) > 1e-9 {
+				t.Fatalf("difference area = %.3f, want %.3f", totalArea(diff), tt.diff)
+			}
+
+			clip, err := ClipOutline(a, tt.b)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if math.Abs(totalArea(clip)-tt.clip) > 1e-9 {
+				t.Fatalf("clip area = %.3f, want %.3f", totalArea(clip), tt.clip)
+			}
+
+			// a ring must never run back along itself where the edges overlapped
+			for _, r := range append(append(union, diff...), clip...) {
+				if !isSimpleRing(r) {
+					t.Fatalf("ring %v overlaps itself", r)
+				}
+			}
+		})
+	}
+}
+
+func TestScaledTolerance(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  Polygon
+		verts int
+		area  float64
+	}{
+		{
+			// UTM eastings and northings, where the shared edge is off by
+			// well below a millimetre
+			name:  "projected_coordinates",
+			a:     square(500000, 5000000, 10),
+			b:     Polygon{{500010.0000001, 5000000}, {500020, 5000000}, {500020, 5000010}, {500009.9999999, 5000010.0000001}},
+			verts: 4,
+			area:  200,
+		},
+		{
+			// nanometre-sized squares, far below the old absolute tolerance
+			name:  "micro_scale",
+			a:     square(0, 0, 4e-9),
+			b:     square(2e-9, 2e-9, 4e-9),
+			verts: 8,
+			area:  28e-18,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Union(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("union: %v", err)
+			}
+			if len(got) != 1 || len(got[0]) != tt.verts {
+				t.Fatalf("got %v, want one ring with %d vertices", got, tt.verts)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > tt.area*1e-6 {
+				t.Fatalf("area = %g, want %g", totalArea(got), tt.area)
+			}
+		})
+	}
+}
+
+func TestClipScaleIndependent(t *testing.T) {
+	for _, scale := range []float64{1e-6, 1, 1e6} {
+		t.Run(fmt.Sprint(scale), func(t *testing.T) {
+			// squares of side scale overlapping in 0.75 × 0.5 of it, the target
+			// tilted so that its plane gives the clipped corner a height
+			target := Polygon{{0, 0, 0}, {scale, 0, scale}, {scale, scale, scale}, {0, scale, 0}}
+			clip := square(scale/4, scale/2, scale)
+			want := 0.375 * scale * scale
+
+			tris, err := Clip(target, clip)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if a := totalArea(tris); math.Abs(a-want) > want*1e-9 {
+				t.Fatalf("triangles cover %g, want %g", a, want)
+			}
+
+			rings, err := ClipWithOptions(target, clip, ClipOptions{Output: OutputRings, Z: ZTargetPlane})
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if a := totalArea(rings); math.Abs(a-want) > want*1e-9 {
+				t.Fatalf("rings cover %g, want %g", a, want)
+			}
+			for _, v := range rings[0] {
+				if math.Abs(v[2]-v[0]) > scale*1e-9 {
+					t.Fatalf("vertex %v is off the plane of the target", v)
+				}
+			}
+
+			holed := Region{Exterior: square(0, 0, 4*scale), Holes: Polygons{square(scale, scale, scale)}}
+			tris, err = TriangulateRegion(holed)
+			if err != nil {
+				t.Fatalf("triangulate: %v", err)
+			}
+			if a, want := totalArea(tris), 15*scale*scale; math.Abs(a-want) > want*1e-9 {
+				t.Fatalf("holed square triangulates to %g, want %g", a, want)
+			}
+		})
+	}
+}
+
+func TestClipSmallPieceFarFromOrigin(t *testing.T) {
+	// a 5 mm square a thousand kilometres out, whose area is below the
+	// tolerance taken as an area
+	piece := square(1e6, 1e6, 0.005)
+	got, err := ClipOutline(piece, square(1e6-1, 1e6-1, 2))
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	if a := totalArea(got); math.Abs(a-25e-6) > 1e-12 {
+		t.Fatalf("area = %g, want 2.5e-05", a)
+	}
+}
+
+func TestBooleanBackends(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	a := wobblyCircle(rng, 0, 0, 10, 300)
+	b := wobblyCircle(rng, 5, 3, 8, 200)
+	bowTie := Polygon{{0, 0}, {12, 12}, {12, 0}, {0, 12}}
+
+	ops := []struct {
+		name string
+		run  func(o Boolean) (Polygons, error)
+	}{
+		{"union", func(o Boolean) (Polygons, error) { return o.Union(a, b) }},
+		{"difference", func(o Boolean) (Polygons, error) { return o.Difference(a, b) }},
+		{"xor", func(o Boolean) (Polygons, error) { return o.Xor(a, b) }},
+		{"intersect_all", func(o Boolean) (Polygons, error) { return o.IntersectAll(Polygons{a}, Polygons{b, bowTie}) }},
+		{"union_all", func(o Boolean) (Polygons, error) { return o.UnionAll(Polygons{a}, Polygons{b}) }},
+		{"difference_all", func(o Boolean) (Polygons, error) { return o.DifferenceAll(Polygons{a}, Polygons{b, bowTie}) }},
+		{"xor_all", func(o Boolean) (Polygons, error) { return o.XorAll(Polygons{a}, Polygons{b}) }},
+		{"simplify", func(o Boolean) (Polygons, error) { return o.SimplifyPolygon(bowTie, NonZero) }},
+		{"clip_fill", func(o Boolean) (Polygons, error) { return o.ClipFill(Polygons{a, bowTie}, Polygons{b}, NonZero) }},
+	}
+
+	for _, op := range ops {
+		t.Run(op.name, func(t *testing.T) {
+			want, err := op.run(Boolean{})
+			if err != nil {
+				t.Fatalf("auto: %v", err)
+			}
+			for _, backend := range []Backend{GraphBackend, SweepBackend} {
+				got, err := op.run(Boolean{Backend: backend})
+				if err != nil {
+					t.Fatalf("backend %d: %v", backend, err)
+				}
+				if math.Abs(totalArea(got)-totalArea(want)) > 1e-9*math.Abs(totalArea(want)) {
+					t.Fatalf("backend %d gave area %.9f, want %.9f", backend, totalArea(got), totalArea(want))
+				}
+			}
+			if _, err := op.run(Boolean{Backend: SweepBackend + 1}); err == nil {
+				t.Fatalf("expected an error for an unknown backend")
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package clippoly
+
+import "math"
+
+// Location tells where a point lies relative to a polygon.
+type Location int
+
+const (
+	// Outside means the point is not covered by the polygon.
+	Outside Location = iota
+	// Inside means the point is covered by the polygon and not on its
+	// boundary.
+	Inside
+	// OnBoundary means the point lies on an edge of the polygon, within
+	// the tolerance used for clipping it.
+	OnBoundary
+)
+
+// ClassifyPoint tells whether pt lies inside poly, outside it or on its
+// boundary. Self-overlapping polygons are filled according to rule, and
+// unknown rules count as EvenOdd. Use PreparePolygon to classify many points
+// against the same polygon.
+func ClassifyPoint(pt Coord, poly Polygon, rule FillRule) Location {
+	if scaledTolerance(Polygons{poly}).verticesOnBoundary(Polygon{pt}, poly) {
+		return OnBoundary
+	}
+	if isInsidePolygon(pt, poly, rule) {
+		return Inside
+	}
+	return Outside
+}
+
+// PreparedPolygon is a polygon with its edges indexed by height, so that
+// classifying a point only looks at the few edges level with it.
+type PreparedPolygon struct {
+	poly   Polygon
+	rule   FillRule
+	tol    tolerance
+	bounds bounds
+	// bands[k] holds the start index of every edge that reaches into the
+	// k-th horizontal band of the bounding box, widened by the tolerance.
+	// There are about √n bands, so that tall edges, which go into every
+	// band, take O(n√n) memory at most.
+	bands      [][]int
+	bandHeight float64
+}
+
+// PreparePolygon indexes poly for ClassifyPoints and
+// PreparedPolygon.Classify. It fails for an unknown fill rule.
+func PreparePolygon(poly Polygon, rule FillRule) (*PreparedPolygon, error) {
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, err
+	}
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+
+	p := &PreparedPolygon{
+		poly:   poly,
+		rule:   rule,
+		tol:    scaledTolerance(Polygons{poly}),
+		bounds: polygonBounds(poly),
+		bands:  make([][]int, int(math.Ceil(math.Sqrt(float64(len(poly)))))),
+	}
+	p.bandHeight = (p.bounds.maxY - p.bounds.minY) / float64(len(p.bands))
+
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		lo := p.band(math.Min(a[1], b[1]) - p.tol.eps)
+		hi := p.band(math.Max(a[1], b[1]) + p.tol.eps)
+		for k := lo; k <= hi; k++ {
+			p.bands[k] = append(p.bands[k], i)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *PreparedPolygon) band(y float64) int {
+	if p.bandHeight == 0 {
+		return 0
+	}
+	k := int((y - p.bounds.minY) / p.bandHeight)
+	return min(max(k, 0), len(p.bands)-1)
+}
+
+// Classify tells whether pt lies inside the polygon, outside it or on its
+// boundary, like ClassifyPoint.
+func (p *PreparedPolygon) Classify(pt Coord) Location {
+	b, eps := p.bounds, p.tol.eps
+	if pt[0] < b.minX-eps || pt[0] > b.maxX+eps || pt[1] < b.minY-eps || pt[1] > b.maxY+eps {
+		return Outside
+	}
+
+	// Every edge that pt can lie on, or that crosses the ray running right
+	// from it, reaches into the band of pt
+	w := 0
+	for _, i := range p.bands[p.band(pt[1])] {
+		a, c := p.poly[i], p.poly[(i+1)%len(p.poly)]
+		if p.tol.pointOnEdge(pt[0], pt[1], a[0], a[1], c[0], c[1]) {
+			return OnBoundary
+		}
+		w += crossing(pt, a, c)
+	}
+	if p.rule.contains(w) {
+		return Inside
+	}
+	return Outside
+}
+
+// ClassifyPoints classifies every point of pts against poly, as
+// ClassifyPoint does, indexing poly once for all of them.
+func ClassifyPoints(pts []Coord, poly Polygon, rule FillRule) ([]Location, error) {
+	p, err := PreparePolygon(poly, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Location, len(pts))
+	for i, pt := range pts {
+		out[i] = p.Classify(pt)
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package clippoly
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestClassifyPoint(t *testing.T) {
+	// U shape opening upwards, 3 wide and 3 high with a 1x2 notch
+	u := Polygon{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}}
+
+	tests := []struct {
+		name string
+		pt   Coord
+		want Location
+	}{
+		{name: "base", pt: Coord{1.5, 0.5}, want: Inside},
+		{name: "arm", pt: Coord{0.5, 2.5}, want: Inside},
+		{name: "notch", pt: Coord{1.5, 2}, want: Outside},
+		{name: "far", pt: Coord{10, 10}, want: Outside},
+		{name: "vertex", pt: Coord{2, 1}, want: OnBoundary},
+		{name: "edge", pt: Coord{3, 1.5}, want: OnBoundary},
+		{name: "notch_floor", pt: Coord{1.5, 1}, want: OnBoundary},
+		{name: "near_edge", pt: Coord{3, 1.5 + 1e-12}, want: OnBoundary},
+		{name: "level_with_vertex", pt: Coord{-1, 1}, want: Outside},
+	}
+
+	prepared, err := PreparePolygon(u, EvenOdd)
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyPoint(tt.pt, u, EvenOdd); got != tt.want {
+				t.Fatalf("ClassifyPoint(%v) = %d, want %d", tt.pt, got, tt.want)
+			}
+			if got := prepared.Classify(tt.pt); got != tt.want {
+				t.Fatalf("Classify(%v) = %d, want %d", tt.pt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyPointsMatchesClassifyPoint(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	poly := wobblyCircle(rng, 0, 0, 10, 500)
+
+	// random points plus every vertex and edge midpoint
+	var pts []Coord
+	for i := 0; i < 5000; i++ {
+		pts = append(pts, Coord{24*rng.Float64() - 12, 24*rng.Float64() - 12})
+	}
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		pts = append(pts, a, Coord{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2})
+	}
+
+	got, err := ClassifyPoints(pts, poly, NonZero)
+	if err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	counts := map[Location]int{}
+	for i, pt := range pts {
+		want := ClassifyPoint(pt, poly, NonZero)
+		if got[i] != want {
+			t.Fatalf("point %v: got %d, want %d", pt, got[i], want)
+		}
+		counts[want]++
+	}
+	if counts[Inside] == 0 || counts[Outside] == 0 || counts[OnBoundary] < 2*len(poly) {
+		t.Fatalf("unexpected mix of locations: %v", counts)
+	}
+}
+
+func TestPreparePolygonTallEdges(t *testing.T) {
+	// a comb whose teeth all run the full height of the polygon
+	const teeth = 2000
+	var poly Polygon
+	for i := 0; i < teeth; i++ {
+		x := float64(2 * i)
+		poly = append(poly, Coord{x, 0}, Coord{x, 100}, Coord{x + 1, 100}, Coord{x + 1, 1})
+	}
+	poly = append(poly, Coord{2 * teeth, 1}, Coord{2 * teeth, 0})
+	poly = orientRing(poly, true)
+
+	p, err := PreparePolygon(poly, NonZero)
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	entries := 0
+	for _, band := range p.bands {
+		entries += len(band)
+	}
+	// one band per vertex would store every tooth about len(poly) times
+	if entries > 100*len(poly) {
+		t.Fatalf("%d bands hold %d edges for %d vertices", len(p.bands), entries, len(poly))
+	}
+
+	for _, pt := range []Coord{{0.5, 50}, {1.5, 50}, {2*teeth - 1.5, 99}, {2*teeth - 0.5, 0.5}, {-1, 50}} {
+		if got, want := p.Classify(pt), ClassifyPoint(pt, poly, NonZero); got != want {
+			t.Fatalf("Classify(%v) = %d, want %d", pt, got, want)
+		}
+	}
+}
+
+func TestPreparePolygonUnknownFillRule(t *testing.T) {
+	if _, err := PreparePolygon(square(0, 0, 1), Negative+1); err == nil {
+		t.Fatalf("expected an error for an unknown fill rule")
+	}
+	if _, err := ClassifyPoints([]Coord{{0.5, 0.5}}, square(0, 0, 1), -1); err == nil {
+		t.Fatalf("expected an error for an unknown fill rule")
+	}
+}
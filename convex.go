@@ -0,0 +1,168 @@
+package clippoly
+
+import "math"
+
+// isConvex checks if poly is a convex ring of either winding. Repeated and
+// collinear vertices are allowed; rings that wind around more than once are
+// not convex.
+func isConvex(poly Polygon) bool {
+	n := len(poly)
+	if n < 3 {
+		return false
+	}
+
+	turn, firstDx, lastDx, dxFlips := 0, 0, 0, 0
+	for i := range poly {
+		a, b, c := poly[i], poly[(i+1)%n], poly[(i+2)%n]
+		if s := signOf(orient(a, b, c)); s != 0 {
+			if turn != 0 && s != turn {
+				return false
+			}
+			turn = s
+		}
+		if dx := signOf(b[0] - a[0]); dx != 0 {
+			if firstDx == 0 {
+				firstDx = dx
+			} else if dx != lastDx {
+				dxFlips++
+			}
+			lastDx = dx
+		}
+	}
+	if lastDx != firstDx {
+		dxFlips++
+	}
+
+	// A convex ring runs right once and left once
+	return turn != 0 && dxFlips <= 2
+}
+
+func signOf(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// convexFastPath checks if target and clip can go through the
+// Sutherland–Hodgman clipper instead of the overlay. Both must be convex and
+// fill their whole interior under rule; integer mode always uses the exact
+// overlay.
+func (tol tolerance) convexFastPath(target Polygon, clip Region, rule FillRule) bool {
+	if tol.integer || len(clip.Holes) > 0 || (rule != EvenOdd && rule != NonZero) {
+		return false
+	}
+	return isConvex(target) && isConvex(clip.Exterior)
+}
+
+// convexClipper clips polygons against a convex ring with the
+// Sutherland–Hodgman algorithm. It reuses its buffers between calls, so
+// clipping many small polygons, such as mesh faces, does not allocate.
+type convexClipper struct {
+	ring    Polygon
+	lengths []float64
+	tol     tolerance
+	in, out Polygon
+}
+
+// newConvexClipper prepares clipping against clip, which must be convex
+func newConvexClipper(clip Polygon, tol tolerance) *convexClipper {
+	c := &convexClipper{ring: orientRing(clip, true), tol: tol}
+	c.lengths = make([]float64, len(c.ring))
+	for i, a := range c.ring {
+		b := c.ring[(i+1)%len(c.ring)]
+		c.lengths[i] = math.Hypot(b[0]-a[0], b[1]-a[1])
+	}
+	return c
+}
+
+// clip returns the part of the convex polygon subject inside the clip ring,
+// in the winding of subject. As in the overlay, points within the tolerance
+// of a clip edge count as lying on it, new vertices take their height from
+// the subject edge they lie on and clip corners keep their own coordinates.
+// The result is only valid until the next call and is empty when nothing but
+// a point or a line remains.
+func (c *convexClipper) clip(subject Polygon) Polygon {
+	c.in = append(c.in[:0], subject...)
+
+	for i := range c.ring {
+		a, b := c.ring[i], c.ring[(i+1)%len(c.ring)]
+		eps := c.tol.eps * c.lengths[i]
+		c.out = c.out[:0]
+		for j := range c.in {
+			p, q := c.in[j], c.in[(j+1)%len(c.in)]
+			dp, dq := orient(a, b, p), orient(a, b, q)
+			if dp >= -eps {
+				c.out = append(c.out, p)
+			}
+			if (dp > eps && dq < -eps) || (dp < -eps && dq > eps) {
+				c.out = append(c.out, cutEdge(p, q, dp, dq))
+			}
+		}
+		c.in, c.out = c.out, c.in
+		if len(c.in) == 0 {
+			return nil
+		}
+	}
+
+	ring := c.tol.compact(c.in)
+	for i, v := range ring {
+		if !vertexOf(subject, v) {
+			ring[i] = c.snapToCorner(v)
+		}
+	}
+	if len(ring) < 3 || c.tol.negligible(ring) {
+		return nil
+	}
+	return ring
+}
+
+// cutEdge returns the point where p-q crosses the clip line, given the
+// orientations dp and dq of p and q against it. The edge is always cut from
+// its smaller end point, so that faces sharing an edge get the same point.
+func cutEdge(p, q Coord, dp, dq float64) Coord {
+	if lessCoord(q, p) {
+		p, q, dp, dq = q, p, dq, dp
+	}
+	t := dp / (dp - dq)
+	return Coord{p[0] + t*(q[0]-p[0]), p[1] + t*(q[1]-p[1]), p[2] + t*(q[2]-p[2])}
+}
+
+func vertexOf(poly Polygon, v Coord) bool {
+	for _, p := range poly {
+		if p == v {
+			return true
+		}
+	}
+	return false
+}
+
+// snapToCorner returns the clip corner that v coincides with, or v itself
+func (c *convexClipper) snapToCorner(v Coord) Coord {
+	for _, corner := range c.ring {
+		if c.tol.coordsEqual(v, corner) {
+			return corner
+		}
+	}
+	return v
+}
+
+// compact drops repeated and collinear vertices from ring in place
+func (tol tolerance) compact(ring Polygon) Polygon {
+	for changed := true; changed && len(ring) >= 3; {
+		changed = false
+		for i := 0; i < len(ring) && len(ring) >= 3; i++ {
+			prev := ring[(i-1+len(ring))%len(ring)]
+			next := ring[(i+1)%len(ring)]
+			if tol.coordsEqual(prev, ring[i]) || tol.isRedundant(prev, ring[i], next) {
+				ring = append(ring[:i], ring[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return ring
+}
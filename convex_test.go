@@ -0,0 +1,74 @@
+package clippoly
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestIsConvex(t *testing.T) {
+	tests := []struct {
+		name string
+		poly Polygon
+		want bool
+	}{
+		{name: "square", poly: square(0, 0, 1), want: true},
+		{name: "clockwise_triangle", poly: Polygon{{0, 0}, {0, 1}, {1, 0}}, want: true},
+		{name: "collinear_vertex", poly: Polygon{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}}, want: true},
+		{name: "repeated_vertex", poly: Polygon{{0, 0}, {2, 0}, {2, 0}, {2, 2}}, want: true},
+		{name: "l_shape", poly: Polygon{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}, want: false},
+		{name: "pentagram", poly: Polygon{{0, 3}, {2, -3}, {-3, 1}, {3, 1}, {-2, -3}}, want: false},
+		{name: "line", poly: Polygon{{0, 0}, {1, 1}, {2, 2}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConvex(tt.poly); got != tt.want {
+				t.Fatalf("isConvex(%v) = %v, want %v", tt.poly, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvexClipperMatchesOverlay(t *testing.T) {
+	clip := Polygon{{1, 0.5}, {4, 1}, {4.5, 3.5}, {2, 4.5}, {0.5, 3}}
+	tol := scaledTolerance(Polygons{clip})
+	clipper := newConvexClipper(clip, tol)
+
+	// triangles of a 6x6 grid around the clip, in both windings
+	for y := -1.0; y < 5; y++ {
+		for x := -1.0; x < 5; x++ {
+			for _, tri := range []Polygon{
+				{{x, y}, {x + 1, y}, {x + 1, y + 1}},
+				{{x, y}, {x, y + 1}, {x + 1, y + 1}},
+			} {
+				var want float64
+				regions, err := tol.intersectRegions(context.Background(), Region{Exterior: tri}, Region{Exterior: clip}, EvenOdd)
+				if err != nil {
+					t.Fatalf("overlay %v: %v", tri, err)
+				}
+				for _, r := range regions {
+					want += regionArea(r)
+				}
+
+				piece := clipper.clip(tri)
+				if got := math.Abs(signedArea(piece)); math.Abs(got-want) > 1e-9 {
+					t.Fatalf("clip %v: area %.6f, want %.6f", tri, got, want)
+				}
+				if len(piece) > 0 && (signedArea(piece) > 0) != (signedArea(tri) > 0) {
+					t.Fatalf("clip %v: piece %v does not keep the triangle winding", tri, piece)
+				}
+			}
+		}
+	}
+}
+
+func TestConvexClipperDoesNotAllocate(t *testing.T) {
+	clipper := newConvexClipper(square(0, 0, 2), defaultTolerance)
+	tri := Polygon{{-1, -1}, {3, 0.5}, {0.5, 3}}
+	clipper.clip(tri)
+
+	if allocs := testing.AllocsPerRun(100, func() { clipper.clip(tri) }); allocs != 0 {
+		t.Fatalf("clip allocated %.0f times per call, want 0", allocs)
+	}
+}
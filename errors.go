@@ -0,0 +1,110 @@
+package clippoly
+
+import "fmt"
+
+// DegenerateInputError reports an input that cannot be clipped because it
+// has too few distinct vertices: three for a ring, two for a line.
+type DegenerateInputError struct {
+	// Name identifies the input, e.g. "target polygon" or "clip hole 2".
+	Name    string
+	Polygon Polygon
+	// Min is the number of distinct vertices the input needs.
+	Min int
+}
+
+func (e *DegenerateInputError) Error() string {
+	if len(e.Polygon) >= e.Min {
+		return fmt.Sprintf("%s must have at least %d distinct vertices, got %v", e.Name, e.Min, e.Polygon)
+	}
+	return fmt.Sprintf("%s must have at least %d vertices, got %d", e.Name, e.Min, len(e.Polygon))
+}
+
+// LoopNotClosedError reports a result ring that could not be traced back to
+// its start, which points at inconsistent noding of the inputs.
+type LoopNotClosedError struct {
+	Target, Clip Polygons
+	// Loop holds the vertices traced so far, ending where tracing stopped.
+	Loop Polygon
+}
+
+func (e *LoopNotClosedError) Error() string {
+	if len(e.Loop) == 0 {
+		return "ring does not close"
+	}
+	at := e.Loop[len(e.Loop)-1]
+	return fmt.Sprintf("ring does not close at (%g, %g) after %d vertices", at[0], at[1], len(e.Loop))
+}
+
+// IterationLimitError reports that tracing a ring took more steps than the
+// inputs can account for.
+type IterationLimitError struct {
+	Target, Clip Polygons
+	Limit        int
+	// Loop holds the vertices traced before giving up.
+	Loop Polygon
+}
+
+func (e *IterationLimitError) Error() string {
+	return fmt.Sprintf("ring tracing exceeded %d iterations", e.Limit)
+}
+
+// RegionError reports rings that do not bound a region, for example a hole
+// that lies outside the exterior.
+type RegionError struct {
+	// At is a vertex where the rings fail to bound a region.
+	At Coord
+}
+
+func (e *RegionError) Error() string {
+	return fmt.Sprintf("rings do not bound a region at (%g, %g)", e.At[0], e.At[1])
+}
+
+// FaceIndexError reports a mesh face that refers to a vertex the mesh does
+// not have.
+type FaceIndexError struct {
+	Face     int
+	Indices  [3]int
+	Vertices int
+}
+
+func (e *FaceIndexError) Error() string {
+	return fmt.Sprintf("face %d: vertex indices %v out of range for %d vertices", e.Face, e.Indices, e.Vertices)
+}
+
+// validatePolygon returns a DegenerateInputError when poly has fewer than
+// three vertices.
+func validatePolygon(name string, poly Polygon) error {
+	if len(poly) < 3 {
+		return &DegenerateInputError{Name: name, Polygon: poly, Min: 3}
+	}
+	return nil
+}
+
+// validatePolyline returns a DegenerateInputError when line has fewer than
+// two vertices.
+func validatePolyline(name string, line []Coord) error {
+	if len(line) < 2 {
+		return &DegenerateInputError{Name: name, Polygon: line, Min: 2}
+	}
+	return nil
+}
+
+// validateFace returns a FaceIndexError when face fi refers to a vertex
+// outside vertices.
+func validateFace(vertices []Coord, fi int, face [3]int) error {
+	for _, v := range face {
+		if v < 0 || v >= len(vertices) {
+			return &FaceIndexError{Face: fi, Indices: face, Vertices: len(vertices)}
+		}
+	}
+	return nil
+}
+
+// nodeCoords returns the coordinates of nodes as a polygon
+func nodeCoords(nodes []*node) Polygon {
+	poly := make(Polygon, len(nodes))
+	for i, n := range nodes {
+		poly[i] = n.coord
+	}
+	return poly
+}
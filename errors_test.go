@@ -0,0 +1,161 @@
+package clippoly
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDegenerateInputError(t *testing.T) {
+	line := Polygon{{0, 0}, {1, 1}}
+
+	tests := []struct {
+		name string
+		err  func() error
+		want string
+	}{
+		{name: "clip", want: "clip polygon", err: func() error {
+			_, err := Clip(square(0, 0, 1), line)
+			return err
+		}},
+		{name: "region_hole", want: "target hole 0", err: func() error {
+			_, err := ClipRegion(Region{Exterior: square(0, 0, 4), Holes: Polygons{line}}, Region{Exterior: square(0, 0, 1)})
+			return err
+		}},
+		{name: "layer", want: "layer b polygon 1", err: func() error {
+			_, err := UnionAll(Polygons{square(0, 0, 1)}, Polygons{square(0, 0, 1), line})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var degenerate *DegenerateInputError
+			if err := tt.err(); !errors.As(err, &degenerate) {
+				t.Fatalf("expected a DegenerateInputError, got %v", err)
+			}
+			if degenerate.Name != tt.want || len(degenerate.Polygon) != len(line) {
+				t.Fatalf("got error for %q with %v, want %q", degenerate.Name, degenerate.Polygon, tt.want)
+			}
+		})
+	}
+}
+
+func TestDegenerateLineError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func() error
+		want string
+		line Polygon
+	}{
+		{name: "clip_line", want: "line", line: Polygon{{0, 0}}, err: func() error {
+			_, err := ClipLine([]Coord{{0, 0}}, square(0, 0, 1))
+			return err
+		}},
+		{name: "split_by_polyline", want: "cut", line: Polygon{{0, 0}}, err: func() error {
+			_, err := SplitByPolyline(square(0, 0, 1), []Coord{{0, 0}})
+			return err
+		}},
+		{name: "split_by_line", want: "line", line: Polygon{{1, 1}, {1, 1}}, err: func() error {
+			_, _, err := SplitByLine(square(0, 0, 2), Coord{1, 1}, Coord{1, 1})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var degenerate *DegenerateInputError
+			if err := tt.err(); !errors.As(err, &degenerate) {
+				t.Fatalf("expected a DegenerateInputError, got %v", err)
+			}
+			if degenerate.Name != tt.want || degenerate.Min != 2 || len(degenerate.Polygon) != len(tt.line) {
+				t.Fatalf("got error for %q needing %d with %v, want %q needing 2 with %v",
+					degenerate.Name, degenerate.Min, degenerate.Polygon, tt.want, tt.line)
+			}
+		})
+	}
+}
+
+func TestRegionError(t *testing.T) {
+	_, err := TriangulateRegion(Region{Exterior: square(0, 0, 10), Holes: Polygons{square(20, 20, 1)}})
+
+	var region *RegionError
+	if !errors.As(err, &region) {
+		t.Fatalf("expected a RegionError, got %v", err)
+	}
+	if region.At[0] < 20 || region.At[1] < 20 {
+		t.Fatalf("error at %v, want a vertex of the stray hole", region.At)
+	}
+}
+
+func TestFaceIndexError(t *testing.T) {
+	vertices := []Coord{{0, 0}, {4, 0}, {4, 4}}
+	faces := [][3]int{{0, 1, 2}, {0, 2, 3}}
+
+	tests := []struct {
+		name string
+		err  func() error
+	}{
+		{name: "clip_mesh", err: func() error {
+			_, _, err := ClipMesh(vertices, faces, Polygon{{1, -1}, {3, 2}, {1, 5}})
+			return err
+		}},
+		{name: "clip_mesh_rect", err: func() error {
+			_, _, err := ClipMeshRect(vertices, faces, 1, 1, 3, 3)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var index *FaceIndexError
+			if err := tt.err(); !errors.As(err, &index) {
+				t.Fatalf("expected a FaceIndexError, got %v", err)
+			}
+			if index.Face != 1 || index.Indices != faces[1] || index.Vertices != len(vertices) {
+				t.Fatalf("got error for face %d %v of %d vertices, want face 1 %v of %d",
+					index.Face, index.Indices, index.Vertices, faces[1], len(vertices))
+			}
+		})
+	}
+}
+
+func TestLoopNotClosedError(t *testing.T) {
+	a := &node{id: 1, coord: Coord{0, 0}}
+	b := &node{id: 2, coord: Coord{1, 0}}
+	c := &node{id: 3, coord: Coord{1, 1}}
+
+	// the edge back from c to a is missing
+	_, err := defaultTolerance.traceRings(context.Background(), []*overlayEdge{{from: a, to: b}, {from: b, to: c}}, traceLimit(3))
+
+	var open *LoopNotClosedError
+	if !errors.As(err, &open) {
+		t.Fatalf("expected a LoopNotClosedError, got %v", err)
+	}
+	if want := (Polygon{a.coord, b.coord, c.coord}); len(open.Loop) != len(want) || open.Loop[2] != want[2] {
+		t.Fatalf("partial loop = %v, want %v", open.Loop, want)
+	}
+}
+
+func TestIterationLimitError(t *testing.T) {
+	a := &node{id: 1, coord: Coord{0, 0}}
+	b := &node{id: 2, coord: Coord{1, 0}}
+	c := &node{id: 3, coord: Coord{1, 1}}
+	ring := []*overlayEdge{{from: a, to: b}, {from: b, to: c}, {from: c, to: a}}
+
+	if _, err := defaultTolerance.traceRings(context.Background(), ring, 3); err != nil {
+		t.Fatalf("trace within the limit: %v", err)
+	}
+	for _, e := range ring {
+		e.used = false
+	}
+
+	_, err := defaultTolerance.traceRings(context.Background(), ring, 2)
+	var limit *IterationLimitError
+	if !errors.As(err, &limit) {
+		t.Fatalf("expected an IterationLimitError, got %v", err)
+	}
+	if limit.Limit != 2 || len(limit.Loop) != 2 {
+		t.Fatalf("got limit %d with loop %v, want limit 2 after 2 vertices", limit.Limit, limit.Loop)
+	}
+}
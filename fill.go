@@ -0,0 +1,83 @@
+package clippoly
+
+import "fmt"
+
+// FillRule decides which parts of the plane enclosed by a set of rings count
+// as inside, based on how often the rings wind around them.
+type FillRule int
+
+const (
+	// EvenOdd fills areas enclosed an odd number of times.
+	EvenOdd FillRule = iota
+	// NonZero fills areas with a non-zero winding number.
+	NonZero
+	// Positive fills areas wound counter-clockwise more often than clockwise.
+	Positive
+	// Negative fills areas wound clockwise more often than counter-clockwise.
+	Negative
+)
+
+func (r FillRule) validate() error {
+	if r < EvenOdd || r > Negative {
+		return fmt.Errorf("unknown fill rule %d", r)
+	}
+	return nil
+}
+
+func (r FillRule) contains(winding int) bool {
+	switch r {
+	case NonZero:
+		return winding != 0
+	case Positive:
+		return winding > 0
+	case Negative:
+		return winding < 0
+	default:
+		return winding%2 != 0
+	}
+}
+
+// windingNumber counts how often poly winds counter-clockwise around pt
+func windingNumber(pt Coord, poly Polygon) int {
+	w := 0
+	for i := range poly {
+		w += crossing(pt, poly[i], poly[(i+1)%len(poly)])
+	}
+	return w
+}
+
+// ClipFill intersects two sets of rings, each interpreted under rule, and
+// returns the triangulated result. Overlapping rings within a set, as often
+// found in CAD exports, are filled according to their winding numbers, and
+// self-intersecting rings are resolved as by SimplifyPolygon before clipping.
+// Use it instead of Clip for inputs that may not be simple. The triangles run
+// counter-clockwise.
+func ClipFill(target, clip Polygons, rule FillRule) (Polygons, error) {
+	return Boolean{}.ClipFill(target, clip, rule)
+}
+
+// ClipFill is ClipFill with the backend of o.
+func (o Boolean) ClipFill(target, clip Polygons, rule FillRule) (Polygons, error) {
+	if err := validateLayer("target", target); err != nil {
+		return nil, err
+	}
+	if err := validateLayer("clip", clip); err != nil {
+		return nil, err
+	}
+
+	rings, err := o.overlay(target, clip, opIntersection, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var triangles Polygons
+	for _, r := range scaledTolerance(target, clip).regions(rings) {
+		tris, err := TriangulateRegion(r)
+		if err != nil {
+			return nil, err
+		}
+		triangles = append(triangles, tris...)
+	}
+
+	return triangles, nil
+}
@@ -0,0 +1,69 @@
+package clippoly
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClipFillRules(t *testing.T) {
+	clip := Polygons{square(-10, -10, 30)}
+	// the clip has to be wound clockwise to count as filled under Negative
+	clipCW := Polygons{orientRing(square(-10, -10, 30), false)}
+	sameWay := Polygons{square(0, 0, 2), square(1, 1, 2)}
+	opposite := Polygons{square(0, 0, 2), orientRing(square(1, 1, 2), false)}
+
+	tests := []struct {
+		name   string
+		target Polygons
+		clip   Polygons
+		rule   FillRule
+		area   float64
+	}{
+		{name: "even_odd", target: sameWay, clip: clip, rule: EvenOdd, area: 6},
+		{name: "non_zero", target: sameWay, clip: clip, rule: NonZero, area: 7},
+		{name: "positive", target: sameWay, clip: clip, rule: Positive, area: 7},
+		{name: "negative", target: sameWay, clip: clipCW, rule: Negative, area: 0},
+		{name: "negative_ccw_clip", target: opposite, clip: clip, rule: Negative, area: 0},
+		{name: "opposite_non_zero", target: opposite, clip: clip, rule: NonZero, area: 6},
+		{name: "opposite_positive", target: opposite, clip: clip, rule: Positive, area: 3},
+		{name: "opposite_negative", target: opposite, clip: clipCW, rule: Negative, area: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClipFill(tt.target, tt.clip, tt.rule)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("area = %.3f, want %.3f", totalArea(got), tt.area)
+			}
+		})
+	}
+}
+
+func TestPointInPolygonFillRules(t *testing.T) {
+	// A counter-clockwise ring that winds twice around the inner square
+	loop := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 1}, {3, 1}, {3, 3}, {1, 3}, {1, 0.5}, {0, 0.5}}
+	pt := Coord{2, 2}
+
+	tests := []struct {
+		rule FillRule
+		want bool
+	}{
+		{EvenOdd, false},
+		{NonZero, true},
+		{Positive, true},
+		{Negative, false},
+	}
+
+	for _, tt := range tests {
+		if got := isInsidePolygon(pt, loop, tt.rule); got != tt.want {
+			t.Fatalf("isInsidePolygon rule %d = %v, want %v", tt.rule, got, tt.want)
+		}
+		nodes := makeShapeWithID(loop, false, &idGenerator{})
+		if got := defaultTolerance.isInsideNodes(&node{coord: pt}, nodes, tt.rule); got != tt.want {
+			t.Fatalf("isInsideNodes rule %d = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package clippoly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"sort"
+)
+
+// MaxIntCoord bounds the coordinates accepted by ClipInt. Within this range
+// every coordinate, and the sum of any two, is exact in float64 and all
+// products in the noding fit in 128 bits.
+const MaxIntCoord = 1 << 52
+
+// IntCoord is a point on an integer grid, such as nanometres in CAD data.
+type IntCoord [3]int64
+
+type IntPolygon []IntCoord
+
+type IntPolygons []IntPolygon
+
+// integerTolerance nodes whole-number coordinates exactly
+var integerTolerance = tolerance{eps: 0.5, integer: true}
+
+// ClipInt is Clip for integer coordinates. The inputs are snap rounded:
+// crossings are computed exactly and rounded to the nearest grid point, and
+// every edge passing through the unit square around a vertex or rounded
+// crossing is routed through that grid point. Results are therefore free of
+// new crossings and bit-for-bit repeatable across machines. Heights are
+// interpolated and rounded the same way. All coordinates must lie within
+// ±MaxIntCoord.
+func ClipInt(target, clip IntPolygon) (IntPolygons, error) {
+	t, err := target.toFloat("target polygon")
+	if err != nil {
+		return nil, err
+	}
+	c, err := clip.toFloat("clip polygon")
+	if err != nil {
+		return nil, err
+	}
+
+	triangles, err := integerTolerance.clipPolygon(context.Background(), t, Region{Exterior: c}, ClipOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(IntPolygons, len(triangles))
+	for i, tri := range triangles {
+		out[i] = make(IntPolygon, len(tri))
+		for j, v := range tri {
+			out[i][j] = IntCoord{int64(v[0]), int64(v[1]), int64(math.Round(v[2]))}
+		}
+	}
+	return out, nil
+}
+
+// clipOnGrid rounds target and clip to multiples of opts.Grid, clips them as
+// ClipInt does in grid units and scales the result back.
+func clipOnGrid(ctx context.Context, target, clip Polygon, opts ClipOptions) (Polygons, error) {
+	t, err := toGrid("target polygon", target, opts.Grid)
+	if err != nil {
+		return nil, err
+	}
+	c, err := toGrid("clip polygon", clip, opts.Grid)
+	if err != nil {
+		return nil, err
+	}
+
+	tol := integerTolerance
+	tol.backend = opts.Backend
+	result, err := tol.clipPolygon(ctx, t, Region{Exterior: c}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Grids such as 0.1 are not exact doubles, so k*0.1 can miss the double
+	// closest to the grid point, as 3*0.1 does. Dividing by a whole inverse
+	// is correctly rounded.
+	scale := func(k float64) float64 { return k * opts.Grid }
+	if inv := 1 / opts.Grid; inv == math.Round(inv) {
+		scale = func(k float64) float64 { return k / inv }
+	}
+	for _, poly := range result {
+		for i := range poly {
+			poly[i][0] = scale(poly[i][0])
+			poly[i][1] = scale(poly[i][1])
+		}
+	}
+	return result, nil
+}
+
+// toGrid returns a copy of poly in whole grid units. Heights are kept.
+func toGrid(name string, poly Polygon, grid float64) (Polygon, error) {
+	out := make(Polygon, len(poly))
+	for i, v := range poly {
+		x, y := math.Round(v[0]/grid), math.Round(v[1]/grid)
+		if math.Abs(x) > MaxIntCoord || math.Abs(y) > MaxIntCoord {
+			return nil, fmt.Errorf("%s vertex %d lies more than MaxIntCoord grid steps from the origin: %v", name, i, v)
+		}
+		out[i] = Coord{x, y, v[2]}
+	}
+	return out, nil
+}
+
+// toFloat converts p to float coordinates, which is exact within
+// ±MaxIntCoord
+func (p IntPolygon) toFloat(name string) (Polygon, error) {
+	poly := make(Polygon, len(p))
+	for i, v := range p {
+		for k, x := range v {
+			if x < -MaxIntCoord || x > MaxIntCoord {
+				return nil, fmt.Errorf("%s vertex %d lies outside ±MaxIntCoord: %v", name, i, v)
+			}
+			poly[i][k] = float64(x)
+		}
+	}
+	if err := validatePolygon(name, poly); err != nil {
+		return nil, err
+	}
+	return poly, nil
+}
+
+// snapRounds bounds the rounds of snapRound. Iterated snap rounding is known
+// to settle; the bound only guards against a bug turning into a hang.
+const snapRounds = 1000
+
+// snapRound snap rounds target and clip, whose coordinates must be whole
+// numbers. The unit square around every vertex and every rounded crossing
+// is a hot pixel, and every edge passing through a hot pixel is routed
+// through its centre. Rerouted edges can pass through further hot pixels
+// and, in principle, cross again, so both steps repeat until nothing
+// changes. The rings that come out only meet at shared vertices and along
+// shared edges, which the overlay nodes without rounding.
+func (tol tolerance) snapRound(ctx context.Context, target, clip Polygons) (Polygons, Polygons, error) {
+	rings := make(Polygons, 0, len(target)+len(clip))
+	for _, r := range append(append(Polygons(nil), target...), clip...) {
+		rings = append(rings, tol.dedupRing(r))
+	}
+
+	// Hot pixels by their centre, holding the height of the vertex or
+	// crossing that made them hot
+	hot := make(map[[2]float64]Coord)
+	for _, r := range rings {
+		for _, v := range r {
+			if _, ok := hot[pixelOf(v)]; !ok {
+				hot[pixelOf(v)] = v
+			}
+		}
+	}
+
+	for round := 0; ; round++ {
+		if round == snapRounds {
+			return nil, nil, fmt.Errorf("snap rounding did not settle after %d rounds", snapRounds)
+		}
+
+		// Edges in ring order with target rings first, so that crossings
+		// take their height from the target as in the pairwise noding,
+		// followed by a point segment for every hot pixel
+		ringSegs := make([][]*sweepSegment, len(rings))
+		var segs []*sweepSegment
+		for r, ring := range rings {
+			for i, v := range ring {
+				s := newSweepSegment(&node{coord: v}, &node{coord: ring[(i+1)%len(ring)]}, r)
+				ringSegs[r] = append(ringSegs[r], s)
+				segs = append(segs, s)
+			}
+		}
+		pixels := make([][2]float64, 0, len(hot))
+		for p := range hot {
+			pixels = append(pixels, p)
+		}
+		// Map order is random; sorting keeps the heights of crossings that
+		// round onto the same pixel repeatable
+		sort.Slice(pixels, func(i, j int) bool {
+			return pixels[i][0] < pixels[j][0] || (pixels[i][0] == pixels[j][0] && pixels[i][1] < pixels[j][1])
+		})
+		for _, p := range pixels {
+			n := &node{coord: hot[p]}
+			segs = append(segs, newSweepSegment(n, n, -1))
+		}
+
+		changed := false
+		var crossings []Coord
+		err := tol.eachPair(ctx, segs, func(s, t *sweepSegment) {
+			switch {
+			case s.ring >= 0 && t.ring >= 0:
+				if properCrossing(s.a.coord, s.b.coord, t.a.coord, t.b.coord) {
+					crossings = append(crossings, roundedCrossing(s.a.coord, s.b.coord, t.a.coord, t.b.coord))
+				}
+			case s.ring >= 0 && t.ring < 0:
+				snapToPixel(s, t.a)
+			case s.ring < 0 && t.ring >= 0:
+				snapToPixel(t, s.a)
+			}
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for r := range rings {
+			var ring Polygon
+			for _, s := range ringSegs[r] {
+				ring = append(ring, s.a.coord)
+				if len(s.splits) > 0 {
+					s.sortSplitsExact()
+					for _, n := range s.splits {
+						ring = append(ring, n.coord)
+					}
+					changed = true
+				}
+			}
+			rings[r] = ring
+		}
+		if changed {
+			// Crossings only count once the edges run through every hot
+			// pixel, or an edge would cross its own rounded version
+			continue
+		}
+
+		for _, c := range crossings {
+			if _, ok := hot[pixelOf(c)]; !ok {
+				hot[pixelOf(c)] = c
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return rings[:len(target)], rings[len(target):], nil
+}
+
+// eachPair calls fn for every pair of segments whose bounding boxes touch
+// within tolerance, passing the one that comes first in segs first. Large
+// inputs are swept, small ones compared pair by pair.
+func (tol tolerance) eachPair(ctx context.Context, segs []*sweepSegment, fn func(s, t *sweepSegment)) error {
+	if tol.useSweep(len(segs)) {
+		return tol.sweepPairs(ctx, segs, fn)
+	}
+	stop := newCanceller(ctx)
+	for i, s := range segs {
+		if stop.tick(len(segs) - i) {
+			return stop.Err()
+		}
+		for _, t := range segs[i+1:] {
+			if s.bounds.minX <= t.bounds.maxX+tol.eps && t.bounds.minX <= s.bounds.maxX+tol.eps &&
+				s.bounds.minY <= t.bounds.maxY+tol.eps && t.bounds.minY <= s.bounds.maxY+tol.eps {
+				fn(s, t)
+			}
+		}
+	}
+	return nil
+}
+
+// snapToPixel records the centre p of a hot pixel as a split of s if s
+// passes through the pixel without ending in it
+func snapToPixel(s *sweepSegment, p *node) {
+	a, b, c := s.a.coord, s.b.coord, p.coord
+	if (c[0] == a[0] && c[1] == a[1]) || (c[0] == b[0] && c[1] == b[1]) {
+		return
+	}
+	if edgeHitsPixel(a, b, c) {
+		s.splits = append(s.splits, p)
+	}
+}
+
+// sortSplitsExact orders the split nodes of s from a to b like sortSplits,
+// comparing whole-number coordinates exactly. The pixels an edge passes
+// through follow each other in the order of their centres along it.
+func (s *sweepSegment) sortSplitsExact() {
+	a, b := s.a.coord, s.b.coord
+	dx, dy := int64(b[0]-a[0]), int64(b[1]-a[1])
+	along := func(n *node) int128 {
+		return mul64(int64(n.coord[0]-a[0]), dx).add(mul64(int64(n.coord[1]-a[1]), dy))
+	}
+	sort.Slice(s.splits, func(i, j int) bool {
+		return along(s.splits[i]).sub(along(s.splits[j])).sign() < 0
+	})
+}
+
+// pixelOf returns the centre of the pixel holding v. Pixels are half-open,
+// so that points halfway between two grid points belong to the upper one,
+// as math.Floor(x+0.5) and roundDiv round them.
+func pixelOf(v Coord) [2]float64 {
+	return [2]float64{math.Floor(v[0] + 0.5), math.Floor(v[1] + 0.5)}
+}
+
+// intersectExact returns the crossing of two properly crossing edges with
+// whole-number coordinates, rounded to the nearest grid point. The height is
+// interpolated along a1-a2 and rounded to the nearest float64 only. It
+// returns nil when the crossing rounds onto an end point, which snapping has
+// already connected to both edges.
+func intersectExact(a1, a2, b1, b2 Coord) *node {
+	c := roundedCrossing(a1, a2, b1, b2)
+	for _, end := range []Coord{a1, a2, b1, b2} {
+		if c[0] == end[0] && c[1] == end[1] {
+			return nil
+		}
+	}
+	return &node{coord: c, isInside: true}
+}
+
+// roundedCrossing returns the crossing of two properly crossing edges with
+// whole-number coordinates, rounded to the nearest grid point, with the
+// height interpolated along a1-a2.
+func roundedCrossing(a1, a2, b1, b2 Coord) Coord {
+	ax, ay := int64(a2[0]-a1[0]), int64(a2[1]-a1[1])
+	bx, by := int64(b2[0]-b1[0]), int64(b2[1]-b1[1])
+	cx, cy := int64(b1[0]-a1[0]), int64(b1[1]-a1[1])
+
+	// The crossing lies at a1 + t*(a2-a1) with t = num/den
+	den := mul64(ax, by).sub(mul64(ay, bx)).big()
+	num := mul64(cx, by).sub(mul64(cy, bx)).big()
+
+	along := func(d int64) float64 {
+		return float64(roundDiv(new(big.Int).Mul(num, big.NewInt(d)), den))
+	}
+	z := new(big.Rat).SetFrac(num, den)
+	z.Mul(z, new(big.Rat).SetFloat64(a2[2]-a1[2]))
+	z.Add(z, new(big.Rat).SetFloat64(a1[2]))
+	zf, _ := z.Float64()
+	return Coord{a1[0] + along(ax), a1[1] + along(ay), zf}
+}
+
+// roundDiv returns n/d rounded to the nearest integer, with halves rounded
+// up
+func roundDiv(n, d *big.Int) int64 {
+	if d.Sign() < 0 {
+		n, d = new(big.Int).Neg(n), new(big.Int).Neg(d)
+	}
+	twice := new(big.Int).Lsh(n, 1)
+	twice.Add(twice, d)
+	// Div rounds towards negative infinity for a positive divisor
+	return twice.Div(twice, new(big.Int).Lsh(d, 1)).Int64()
+}
+
+// edgeHitsPixel checks if the edge a-b passes through the pixel centred on
+// the grid point p, the unit square around it that includes its left and
+// bottom sides but not its right and top ones. This way an edge running
+// exactly through a pixel corner only passes through one of the four pixels
+// meeting there.
+func edgeHitsPixel(a, b, p Coord) bool {
+	// Double all coordinates so that the pixel corners are whole numbers
+	x1, y1 := 2*int64(a[0]), 2*int64(a[1])
+	x2, y2 := 2*int64(b[0]), 2*int64(b[1])
+	px, py := 2*int64(p[0]), 2*int64(p[1])
+
+	if max(x1, x2) < px-1 || min(x1, x2) > px+1 || max(y1, y2) < py-1 || min(y1, y2) > py+1 {
+		return false
+	}
+
+	// The line runs through the square if there are corners on both sides
+	// of it. Corners have odd and end points even coordinates, so the edge
+	// cannot end on the boundary or run along a side. Otherwise it can
+	// still touch a single corner, which only the lower left one belongs to
+	// the pixel.
+	var left, right bool
+	touches := -1
+	for k, d := range [4][2]int64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		switch orientInt(x1, y1, x2, y2, px+d[0], py+d[1]) {
+		case 1:
+			left = true
+		case -1:
+			right = true
+		default:
+			touches = k
+		}
+	}
+	return (left && right) || touches == 0
+}
+
+// orientInt returns the sign of orient for whole-number coordinates,
+// computed exactly
+func orientInt(ax, ay, bx, by, cx, cy int64) int {
+	return mul64(ax-cx, by-cy).sub(mul64(ay-cy, bx-cx)).sign()
+}
+
+// int128 is a signed 128-bit integer in two's complement, holding the exact
+// products of coordinate differences
+type int128 struct {
+	hi int64
+	lo uint64
+}
+
+// mul64 returns the exact product of a and b
+func mul64(a, b int64) int128 {
+	hi, lo := bits.Mul64(abs64(a), abs64(b))
+	p := int128{hi: int64(hi), lo: lo}
+	if (a < 0) != (b < 0) {
+		return p.neg()
+	}
+	return p
+}
+
+func abs64(a int64) uint64 {
+	if a < 0 {
+		return uint64(-a)
+	}
+	return uint64(a)
+}
+
+func (x int128) neg() int128 {
+	lo, carry := bits.Add64(^x.lo, 1, 0)
+	return int128{hi: ^x.hi + int64(carry), lo: lo}
+}
+
+func (x int128) add(y int128) int128 {
+	lo, carry := bits.Add64(x.lo, y.lo, 0)
+	return int128{hi: x.hi + y.hi + int64(carry), lo: lo}
+}
+
+func (x int128) sub(y int128) int128 {
+	lo, borrow := bits.Sub64(x.lo, y.lo, 0)
+	return int128{hi: x.hi - y.hi - int64(borrow), lo: lo}
+}
+
+func (x int128) sign() int {
+	switch {
+	case x.hi < 0:
+		return -1
+	case x.hi > 0 || x.lo > 0:
+		return 1
+	}
+	return 0
+}
+
+func (x int128) big() *big.Int {
+	b := big.NewInt(x.hi)
+	b.Lsh(b, 64)
+	return b.Add(b, new(big.Int).SetUint64(x.lo))
+}
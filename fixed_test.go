@@ -0,0 +1,220 @@
+package clippoly
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func intSquare(x, y, size int64) IntPolygon {
+	return IntPolygon{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+}
+
+// intVertices returns the distinct vertices of polys in a fixed order
+func intVertices(polys IntPolygons) []IntCoord {
+	seen := make(map[IntCoord]bool)
+	var out []IntCoord
+	for _, p := range polys {
+		for _, v := range p {
+			if !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		for k := range out[i] {
+			if out[i][k] != out[j][k] {
+				return out[i][k] < out[j][k]
+			}
+		}
+		return false
+	})
+	return out
+}
+
+func intArea2(polys IntPolygons) *big.Int {
+	sum := new(big.Int)
+	for _, p := range polys {
+		for i := range p {
+			a, b := p[i], p[(i+1)%len(p)]
+			sum.Add(sum, mul64(a[0], b[1]).sub(mul64(a[1], b[0])).big())
+		}
+	}
+	return sum
+}
+
+func TestClipIntLargeCoordinates(t *testing.T) {
+	// nanometre squares far from the origin, where float64 products would
+	// already lose precision
+	const o = 1_000_000_000_000_000
+	target := intSquare(o, o, 4_000_000)
+	clip := intSquare(o+1_000_001, o+2_000_003, 4_000_000)
+
+	got, err := ClipInt(target, clip)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+
+	want := []IntCoord{
+		{o + 1_000_001, o + 2_000_003}, {o + 1_000_001, o + 4_000_000},
+		{o + 4_000_000, o + 2_000_003}, {o + 4_000_000, o + 4_000_000},
+	}
+	if v := intVertices(got); len(v) != len(want) {
+		t.Fatalf("got vertices %v, want %v", v, want)
+	} else {
+		for i := range want {
+			if v[i] != want[i] {
+				t.Fatalf("got vertices %v, want %v", v, want)
+			}
+		}
+	}
+	if area2 := intArea2(got); area2.Cmp(big.NewInt(2*2_999_999*1_999_997)) != 0 {
+		t.Fatalf("doubled area = %v, want %d", area2, 2*2_999_999*1_999_997)
+	}
+}
+
+func TestClipIntRoundsCrossings(t *testing.T) {
+	target := IntPolygon{{0, 0, 0}, {10, 0, 0}, {10, 10, 10}, {0, 10, 10}}
+	// the slanted edge crosses y=0 at x=47/6 and x=10 at y=13/5
+	clip := IntPolygon{{-3, -1}, {7, -1}, {12, 5}, {-3, 5}}
+
+	first, err := ClipInt(target, clip)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	got := intVertices(first)
+	want := []IntCoord{{0, 0, 0}, {0, 5, 5}, {8, 0, 0}, {10, 3, 3}, {10, 5, 5}}
+	if len(got) != len(want) {
+		t.Fatalf("got vertices %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got vertices %v, want %v", got, want)
+		}
+	}
+
+	// starting the rings elsewhere must give the very same vertices
+	rotated, err := ClipInt(append(target[2:], target[:2]...), append(clip[1:], clip[0]))
+	if err != nil {
+		t.Fatalf("clip rotated: %v", err)
+	}
+	again := intVertices(rotated)
+	for i := range got {
+		if again[i] != got[i] {
+			t.Fatalf("rotated input gave %v, want %v", again, got)
+		}
+	}
+}
+
+func TestClipIntSnapsToVertices(t *testing.T) {
+	// the clip edge from (-10, 0) to (10, 21) passes within half a unit of
+	// the target corner (0, 10), so it is routed through that corner
+	target := intSquare(0, 0, 10)
+	clip := IntPolygon{{-10, 0}, {10, 21}, {-10, 21}}
+
+	got, err := ClipInt(target, clip)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	for _, tri := range got {
+		for _, v := range tri {
+			if v != (IntCoord{0, 10}) {
+				t.Fatalf("expected only the snapped corner to remain, got %v", got)
+			}
+		}
+	}
+
+	if _, err := ClipInt(IntPolygon{{0, 0}, {MaxIntCoord + 1, 0}, {0, 1}}, clip); err == nil {
+		t.Fatalf("expected an error for coordinates outside ±MaxIntCoord")
+	}
+}
+
+// crossingEdges returns two edges of rings that cross each other away from
+// their end points, if there are any
+func crossingEdges(rings Polygons) ([2]Coord, [2]Coord, bool) {
+	var edges [][2]Coord
+	for _, r := range rings {
+		for i := range r {
+			edges = append(edges, [2]Coord{r[i], r[(i+1)%len(r)]})
+		}
+	}
+	for i, e := range edges {
+		for _, f := range edges[i+1:] {
+			if properCrossing(e[0], e[1], f[0], f[1]) {
+				return e, f, true
+			}
+		}
+	}
+	return [2]Coord{}, [2]Coord{}, false
+}
+
+func (p IntPolygons) toFloat() Polygons {
+	out := make(Polygons, len(p))
+	for i, poly := range p {
+		out[i], _ = poly.toFloat("result")
+	}
+	return out
+}
+
+func TestClipIntSnapRounding(t *testing.T) {
+	// Each pair overlaps in a sliver narrower than a grid step, which snap
+	// rounding collapses. Crossings rounded to the grid used to leave the
+	// other edges running past them, so that the rings did not close.
+	tests := []struct {
+		target, clip IntPolygon
+	}{
+		{target: IntPolygon{{3, 1}, {1, 6}, {3, 5}}, clip: IntPolygon{{4, 3}, {1, 4}, {1, 1}}},
+		{target: IntPolygon{{2, 0}, {7, 5}, {4, 4}}, clip: IntPolygon{{4, 0}, {4, 3}, {0, 5}}},
+		{target: IntPolygon{{3, 2}, {1, 0}, {2, 5}}, clip: IntPolygon{{0, 5}, {2, 5}, {6, 1}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ClipInt(tt.target, tt.clip)
+		if err != nil {
+			t.Fatalf("ClipInt(%v, %v): %v", tt.target, tt.clip, err)
+		}
+		if e, f, ok := crossingEdges(got.toFloat()); ok {
+			t.Fatalf("ClipInt(%v, %v) gave triangles %v with crossing edges %v and %v", tt.target, tt.clip, got, e, f)
+		}
+	}
+}
+
+func TestClipIntRandomTriangles(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	triangle := func() IntPolygon {
+		p := make(IntPolygon, 3)
+		for i := range p {
+			p[i] = IntCoord{rng.Int63n(8), rng.Int63n(8)}
+		}
+		return p
+	}
+
+	for i := 0; i < 5000; i++ {
+		target, clip := triangle(), triangle()
+		if orientInt(target[0][0], target[0][1], target[1][0], target[1][1], target[2][0], target[2][1]) == 0 ||
+			orientInt(clip[0][0], clip[0][1], clip[1][0], clip[1][1], clip[2][0], clip[2][1]) == 0 {
+			continue
+		}
+		got, err := ClipInt(target, clip)
+		if err != nil {
+			t.Fatalf("ClipInt(%v, %v): %v", target, clip, err)
+		}
+		if e, f, ok := crossingEdges(got.toFloat()); ok {
+			t.Fatalf("ClipInt(%v, %v) gave triangles %v with crossing edges %v and %v", target, clip, got, e, f)
+		}
+	}
+}
+
+func TestMul64(t *testing.T) {
+	values := []int64{0, 1, -1, 3, -7, 1 << 62, -(1 << 62), 1<<63 - 1, -1 << 63}
+	for _, a := range values {
+		for _, b := range values {
+			want := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+			if got := mul64(a, b).big(); got.Cmp(want) != 0 {
+				t.Fatalf("mul64(%d, %d) = %v, want %v", a, b, got, want)
+			}
+		}
+	}
+}
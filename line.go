@@ -0,0 +1,93 @@
+package clippoly
+
+import "sort"
+
+// ClipLine returns the parts of the open polyline line that lie inside clip,
+// in the order they are visited. Parts running along the boundary of clip
+// count as inside. Where line crosses the boundary, the new vertices take
+// their height from the line.
+func ClipLine(line []Coord, clip Polygon) ([][]Coord, error) {
+	inside, _, err := ClipLineSides(line, clip)
+	return inside, err
+}
+
+// ClipLineSides is ClipLine that also returns the parts of line outside clip.
+func ClipLineSides(line []Coord, clip Polygon) (inside, outside [][]Coord, err error) {
+	if err := validatePolygon("clip polygon", clip); err != nil {
+		return nil, nil, err
+	}
+	if err := validatePolyline("line", line); err != nil {
+		return nil, nil, err
+	}
+
+	tol := scaledTolerance(Polygons{line, clip})
+
+	var cur []Coord
+	curIn := false
+	flush := func() {
+		if len(cur) < 2 {
+			return
+		}
+		if curIn {
+			inside = append(inside, cur)
+		} else {
+			outside = append(outside, cur)
+		}
+	}
+
+	for i := 0; i+1 < len(line); i++ {
+		a, b := line[i], line[i+1]
+		if tol.coordsEqual(a, b) {
+			continue
+		}
+
+		pts := append(append([]Coord{a}, tol.lineCuts(a, b, clip)...), b)
+		for k := 0; k+1 < len(pts); k++ {
+			p, q := pts[k], pts[k+1]
+			mid := Coord{(p[0] + q[0]) / 2, (p[1] + q[1]) / 2}
+			in := tol.verticesOnBoundary(Polygon{mid}, clip) || isInsidePolygon(mid, clip, EvenOdd)
+			if len(cur) == 0 || in != curIn {
+				flush()
+				cur, curIn = []Coord{p}, in
+			}
+			cur = append(cur, q)
+		}
+	}
+	flush()
+
+	return inside, outside, nil
+}
+
+// lineCuts returns the points strictly between a and b where the segment
+// crosses or touches the boundary of clip, ordered from a to b. Heights are
+// interpolated along a-b.
+func (tol tolerance) lineCuts(a, b Coord, clip Polygon) []Coord {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l2 := dx*dx + dy*dy
+	along := func(p Coord) float64 {
+		return ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / l2
+	}
+
+	var cuts []Coord
+	seg := []*node{{coord: a}, {coord: b}}
+	for i := range clip {
+		c1, c2 := clip[i], clip[(i+1)%len(clip)]
+		if n := tol.findIntersect(seg, []*node{{coord: c1}, {coord: c2}}); n != nil {
+			cuts = append(cuts, n.coord)
+		}
+		if !tol.coordsEqual(c1, a) && !tol.coordsEqual(c1, b) && tol.pointOnEdge(c1[0], c1[1], a[0], a[1], b[0], b[1]) {
+			t := along(c1)
+			cuts = append(cuts, Coord{c1[0], c1[1], a[2] + t*(b[2]-a[2])})
+		}
+	}
+
+	sort.Slice(cuts, func(i, j int) bool { return along(cuts[i]) < along(cuts[j]) })
+	out := cuts[:0]
+	for _, c := range cuts {
+		if len(out) > 0 && tol.coordsEqual(out[len(out)-1], c) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package clippoly
+
+import (
+	"math"
+	"testing"
+)
+
+func lineLength(lines [][]Coord) float64 {
+	var sum float64
+	for _, l := range lines {
+		for i := 0; i+1 < len(l); i++ {
+			sum += math.Hypot(l[i+1][0]-l[i][0], l[i+1][1]-l[i][1])
+		}
+	}
+	return sum
+}
+
+func TestClipLine(t *testing.T) {
+	// U shape opening upwards, 3 wide and 3 high with a 1x2 notch
+	u := Polygon{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}}
+
+	tests := []struct {
+		name            string
+		line            []Coord
+		clip            Polygon
+		inside, outside int
+		inLen, outLen   float64
+	}{
+		{name: "through", line: []Coord{{-1, 1}, {3, 1}}, clip: square(0, 0, 2), inside: 1, outside: 2, inLen: 2, outLen: 2},
+		{name: "across_arms", line: []Coord{{-1, 2}, {4, 2}}, clip: u, inside: 2, outside: 3, inLen: 2, outLen: 3},
+		{name: "starts_inside", line: []Coord{{1, 1}, {1, 3}, {3, 3}}, clip: square(0, 0, 2), inside: 1, outside: 1, inLen: 1, outLen: 3},
+		{name: "along_edge", line: []Coord{{-1, 0}, {3, 0}}, clip: square(0, 0, 2), inside: 1, outside: 2, inLen: 2, outLen: 2},
+		{name: "through_vertex", line: []Coord{{-1, -1}, {3, 3}}, clip: square(0, 0, 2), inside: 1, outside: 2, inLen: 2 * math.Sqrt2, outLen: 2 * math.Sqrt2},
+		{name: "misses", line: []Coord{{3, 0}, {3, 2}}, clip: square(0, 0, 2), inside: 0, outside: 1, inLen: 0, outLen: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inside, outside, err := ClipLineSides(tt.line, tt.clip)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if len(inside) != tt.inside || len(outside) != tt.outside {
+				t.Fatalf("got %d inside and %d outside parts, want %d and %d: %v / %v", len(inside), len(outside), tt.inside, tt.outside, inside, outside)
+			}
+			if math.Abs(lineLength(inside)-tt.inLen) > 1e-9 || math.Abs(lineLength(outside)-tt.outLen) > 1e-9 {
+				t.Fatalf("lengths = %.3f, %.3f, want %.3f, %.3f", lineLength(inside), lineLength(outside), tt.inLen, tt.outLen)
+			}
+		})
+	}
+}
+
+func TestClipLineInterpolatesZ(t *testing.T) {
+	line := []Coord{{-2, 1, 0}, {4, 1, 12}}
+	inside, err := ClipLine(line, square(0, 0, 2))
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	want := [][]Coord{{{0, 1, 4}, {2, 1, 8}}}
+	if len(inside) != 1 || len(inside[0]) != 2 {
+		t.Fatalf("got %v, want %v", inside, want)
+	}
+	for i, v := range inside[0] {
+		for k := range v {
+			if math.Abs(v[k]-want[0][i][k]) > 1e-9 {
+				t.Fatalf("got %v, want %v", inside, want)
+			}
+		}
+	}
+}
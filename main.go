@@ -1,15 +1,43 @@
 package clippoly
 
-import (
-	"fmt"
-	"log"
-	"math"
-)
+import "math"
+
+const eps = 1e-9
+
+// tolerance holds the limits below which the noding code treats coordinates
+// as equal and points as lying on an edge. In integer mode all coordinates
+// are whole numbers and the noding is exact, see ClipInt. backend selects how
+// the edges are noded.
+type tolerance struct {
+	eps     float64
+	integer bool
+	backend Backend
+}
 
-const (
-	eps    = 1e-9
-	denEps = 1e-12
-)
+var defaultTolerance = tolerance{eps: eps}
+
+// relativeEps is the tolerance per unit of coordinate magnitude used when
+// none is given, see scaledTolerance
+const relativeEps = 1e-10
+
+// scaledTolerance returns the tolerance for clipping the given rings, scaled
+// to their largest absolute coordinate. This keeps it meaningful both for
+// projected coordinates in the millions, where float64 cannot resolve 1e-9,
+// and for micro-scale data, where 1e-9 would merge distinct vertices.
+func scaledTolerance(rings ...Polygons) tolerance {
+	scale := 0.0
+	for _, polys := range rings {
+		for _, p := range polys {
+			for _, v := range p {
+				scale = max(scale, math.Abs(v[0]), math.Abs(v[1]))
+			}
+		}
+	}
+	if scale == 0 || math.IsInf(scale, 0) || math.IsNaN(scale) {
+		return defaultTolerance
+	}
+	return tolerance{eps: relativeEps * scale}
+}
 
 type Coord [3]float64
 
@@ -35,10 +63,10 @@ func (g *idGenerator) Next() int {
 	return g.current
 }
 
-func setIsInside(nodes []*node, polygon []*node) bool {
+func (tol tolerance) setIsInside(nodes []*node, polygon []*node) bool {
 	c := 0
 	for _, n := range nodes {
-		if isInsideNodes(n, polygon) {
+		if tol.isInsideNodes(n, polygon, EvenOdd) {
 			n.isInside = true
 			c++
 		}
@@ -81,52 +109,24 @@ func relink(new, from, to, cross1, cross2 *node) {
 
 }
 
-// find all intersetions
-
+// Clip returns the triangulated intersection of target and clip. Every
+// disjoint piece of the intersection is included, and the triangles keep the
+// winding of target.
 func Clip(target, clip Polygon) (triangles Polygons, err error) {
+	return ClipWithOptions(target, clip, ClipOptions{})
+}
 
-	if len(target) < 3 {
-		return nil, fmt.Errorf("target polygon must have at least 3 vertices, got %d", len(target))
-	}
-	if len(clip) < 3 {
-		return nil, fmt.Errorf("clip polygon must have at least 3 vertices, got %d", len(clip))
-	}
-
-	// Early exit: check if polygons don't intersect at all
-	if !polygonsIntersect(target, clip) { // TODO check if improvemnt
-		// Check if target is completely inside or outside clip
-		if isInsidePolygon(target[0], clip) {
-			// Target is completely inside clip, return unedited targer
-			return Polygons{target}, nil
-		}
-		// Target is completely outside clip, return empty
-		return triangulatePolygon(clip), nil
-	}
-
-	idGen := &idGenerator{}
-
-	targetNodes := makeShapeWithID(target, true, idGen)
-	clipNodes := makeShapeWithID(clip, false, idGen)
-
-	areAllInside := setIsInside(targetNodes, clipNodes)
-	if areAllInside {
-		return triangulate(targetNodes) // loop is clippoly
-	}
-	areAllInside = setIsInside(clipNodes, targetNodes)
-	if areAllInside {
-		return triangulate(clipNodes)
-	}
-
-	loop, err := traceIntersectionLoop(targetNodes, clipNodes, idGen)
-	if err != nil {
-		return nil, err
-	}
-
-	return triangulate(loop)
+// ClipOutline returns the intersection of target and clip as rings instead of
+// triangles. Exterior rings run counter-clockwise and holes clockwise, as in
+// Union; pass them through Regions and TriangulateRegion to get the triangles
+// Clip would return.
+func ClipOutline(target, clip Polygon) (Polygons, error) {
+	return ClipWithOptions(target, clip, ClipOptions{Output: OutputRings, Orientation: CounterClockwise})
 }
 
-// polygonsIntersect checks if two polygons have any edge intersections
-func polygonsIntersect(poly1, poly2 Polygon) bool {
+// polygonsIntersect checks if two polygons have any edge intersections. Once
+// stop tells it to, it gives up and reports false.
+func polygonsIntersect(poly1, poly2 Polygon, stop *canceller) bool {
 	// First check bounding boxes for quick rejection
 	if !boundingBoxesOverlap(poly1, poly2) {
 		return false
@@ -134,6 +134,9 @@ func polygonsIntersect(poly1, poly2 Polygon) bool {
 
 	// Check if any edges intersect
 	for i := 0; i < len(poly1); i++ {
+		if stop.tick(len(poly2)) {
+			return false
+		}
 		next := (i + 1) % len(poly1)
 		a1, a2 := poly1[i], poly1[next]
 
@@ -150,50 +153,99 @@ func polygonsIntersect(poly1, poly2 Polygon) bool {
 	return false
 }
 
+// ringIsSimple checks that no two edges of poly cross or overlap, as they do
+// in bow-ties and rings looping over themselves. Once stop tells it to, it
+// gives up and reports false.
+func ringIsSimple(poly Polygon, stop *canceller) bool {
+	for i := range poly {
+		if stop.tick(len(poly) - i) {
+			return false
+		}
+		a1, a2 := poly[i], poly[(i+1)%len(poly)]
+		for j := i + 1; j < len(poly); j++ {
+			if segmentsIntersect(a1, a2, poly[j], poly[(j+1)%len(poly)]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// polygonsTouch checks if a vertex of either polygon lies on the boundary of
+// the other, giving up like polygonsIntersect
+func (tol tolerance) polygonsTouch(poly1, poly2 Polygon, stop *canceller) bool {
+	for _, pair := range [2][2]Polygon{{poly1, poly2}, {poly2, poly1}} {
+		pts, poly := pair[0], pair[1]
+		for i := range pts {
+			if stop.tick(len(poly)) {
+				return false
+			}
+			if tol.verticesOnBoundary(pts[i:i+1], poly) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (tol tolerance) verticesOnBoundary(pts, poly Polygon) bool {
+	for _, p := range pts {
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			if tol.pointOnEdge(p[0], p[1], a[0], a[1], b[0], b[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // boundingBoxesOverlap checks if bounding boxes of two polygons overlap
 func boundingBoxesOverlap(poly1, poly2 Polygon) bool {
 	if len(poly1) == 0 || len(poly2) == 0 {
 		return false
 	}
+	return polygonBounds(poly1).overlaps(polygonBounds(poly2))
+}
 
-	// Calculate bounding box for poly1
-	min1X, max1X := poly1[0][0], poly1[0][0]
-	min1Y, max1Y := poly1[0][1], poly1[0][1]
-	for _, p := range poly1[1:] {
-		if p[0] < min1X {
-			min1X = p[0]
-		}
-		if p[0] > max1X {
-			max1X = p[0]
-		}
-		if p[1] < min1Y {
-			min1Y = p[1]
-		}
-		if p[1] > max1Y {
-			max1Y = p[1]
-		}
+// bounds is an axis-aligned bounding box in plan
+type bounds struct {
+	minX, minY, maxX, maxY float64
+}
+
+func polygonBounds(poly Polygon) bounds {
+	b := bounds{
+		minX: math.Inf(1), minY: math.Inf(1),
+		maxX: math.Inf(-1), maxY: math.Inf(-1),
+	}
+	for _, p := range poly {
+		b.minX = math.Min(b.minX, p[0])
+		b.maxX = math.Max(b.maxX, p[0])
+		b.minY = math.Min(b.minY, p[1])
+		b.maxY = math.Max(b.maxY, p[1])
 	}
+	return b
+}
 
-	// Calculate bounding box for poly2
-	min2X, max2X := poly2[0][0], poly2[0][0]
-	min2Y, max2Y := poly2[0][1], poly2[0][1]
-	for _, p := range poly2[1:] {
-		if p[0] < min2X {
-			min2X = p[0]
-		}
-		if p[0] > max2X {
-			max2X = p[0]
-		}
-		if p[1] < min2Y {
-			min2Y = p[1]
-		}
-		if p[1] > max2Y {
-			max2Y = p[1]
+// finite checks if all sides of the box are finite, which rules out boxes of
+// empty polygons and of polygons with NaN or infinite coordinates
+func (b bounds) finite() bool {
+	for _, v := range []float64{b.minX, b.minY, b.maxX, b.maxY} {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return false
 		}
 	}
+	return true
+}
+
+// overlaps checks if the interiors of both boxes overlap
+func (b bounds) overlaps(o bounds) bool {
+	return b.minX < o.maxX && b.maxX > o.minX && b.minY < o.maxY && b.maxY > o.minY
+}
 
-	// Check overlap
-	return min1X < max2X && max1X > min2X && min1Y < max2Y && max1Y > min2Y
+// touches checks if both boxes overlap or share part of their boundary
+func (b bounds) touches(o bounds) bool {
+	return b.minX <= o.maxX && b.maxX >= o.minX && b.minY <= o.maxY && b.maxY >= o.minY
 }
 
 // segmentsIntersect checks if two line segments intersect (excluding endpoints)
@@ -221,136 +273,56 @@ func segmentsIntersect(a1, a2, b1, b2 Coord) bool {
 		return false
 	}
 
-	// Calculate intersection
-	ax := a2[0] - a1[0]
-	ay := a2[1] - a1[1]
-	bx := b2[0] - b1[0]
-	by := b2[1] - b1[1]
-	den := ax*by - ay*bx
-
-	if den == 0 {
-		return false // Parallel or collinear
+	if orient(a1, a2, b1) == 0 && orient(a1, a2, b2) == 0 {
+		// Collinear segments only meet when they share more than an end
+		// point
+		return segmentsOverlap(a1, a2, b1, b2)
 	}
 
-	cx := b1[0] - a1[0]
-	cy := b1[1] - a1[1]
-	t := (cx*by - cy*bx) / den
-	u := (cx*ay - cy*ax) / den
-
 	// Check if intersection is strictly between endpoints
-	return t > 0 && t < 1 && u > 0 && u < 1
-}
-
-// isInsidePolygon checks if a point is inside a polygon
-func isInsidePolygon(pt Coord, poly Polygon) bool {
-	inside := false
-	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
-		if ((poly[i][1] > pt[1]) != (poly[j][1] > pt[1])) &&
-			(pt[0] < (poly[j][0]-poly[i][0])*(pt[1]-poly[i][1])/(poly[j][1]-poly[i][1])+poly[i][0]) {
-			inside = !inside
-		}
-	}
-	return inside
+	return properCrossing(a1, a2, b1, b2)
 }
 
-// triangulatePolygon is a helper to triangulate a simple polygon
-func triangulatePolygon(poly Polygon) Polygons {
-	if len(poly) < 3 {
-		return nil
+// segmentsOverlap checks if two parallel segments lie on the same line and
+// share a stretch of positive length
+func segmentsOverlap(a1, a2, b1, b2 Coord) bool {
+	if orient(a1, a2, b1) != 0 || orient(a1, a2, b2) != 0 {
+		return false
 	}
 
-	triangles := make([]Polygon, 0, len(poly)-2)
-	for i := 1; i < len(poly)-1; i++ {
-		triangles = append(triangles, Polygon{
-			poly[0],
-			poly[i],
-			poly[i+1],
-		})
+	// Compare the extents along the axis in which a runs the furthest
+	k := 0
+	if math.Abs(a2[1]-a1[1]) > math.Abs(a2[0]-a1[0]) {
+		k = 1
 	}
-
-	return triangles
+	lo := math.Max(math.Min(a1[k], a2[k]), math.Min(b1[k], b2[k]))
+	hi := math.Min(math.Max(a1[k], a2[k]), math.Max(b1[k], b2[k]))
+	return hi > lo
 }
 
-func traceIntersectionLoop(targetNodes, clipNodes []*node, idGen *idGenerator) ([]*node, error) {
-	const maxIterations = 1000 // Use a more reasonable limit
-
-	loop := make([]*node, 0, 12) // Pre-allocate with reasonable capacity
-	curNode := targetNodes[0]
-
-	for range maxIterations {
-
-		nextNode, finished := findNextNode(curNode, loop, targetNodes, clipNodes, idGen)
-
-		if finished {
-			return loop, nil
-		}
-
-		if nextNode == nil {
-			return nil, fmt.Errorf("failed to find next node in intersection loop")
-		}
-
-		loop = append(loop, nextNode)
-
-		// fmt.Printf("nextNode.id: %v\n", nextNode.id)
-		// fmt.Printf("loop: %v\n", loop)
-
-		curNode = nextNode
-
-	}
-
-	return nil, fmt.Errorf("exceeded max iterations (%d) while tracing loop", maxIterations)
+// isInsidePolygon checks if a point is inside a polygon under the given fill rule
+func isInsidePolygon(pt Coord, poly Polygon, rule FillRule) bool {
+	// Every crossing of the ray changes the winding number by one, so its
+	// parity is the even-odd result
+	return rule.contains(windingNumber(pt, poly))
 }
 
-func findNextNode(curNode *node, loop []*node, targetNodes, clipNodes []*node, idGen *idGenerator) (*node, bool) {
-	for _, n := range curNode.nodes {
-		// Check if we've completed the loop
-
-		if len(loop) > 0 && n.id == loop[0].id {
-			return nil, true
-		}
-
-		// Check for intersections
-		nodes := clipNodes
-		if !n.isTarget {
-			nodes = targetNodes
-		}
-
-		var intNode *node
-
-		if intNode = checkIntersections(curNode, n, nodes, idGen); intNode != nil {
-			if intNode.coord == curNode.coord {
-				continue
-			}
-			return intNode, false
-		}
-
-		// Check if node is inside
-		if n.isInside {
-			return n, false
-		}
-	}
-
-	return nil, false
+// withWinding returns poly, reversed if its winding differs from like
+func withWinding(poly, like Polygon) Polygon {
+	return orientRing(poly, signedArea(like) >= 0)
 }
 
-func checkIntersections(curNode, n *node, nodes []*node, idGen *idGenerator) *node {
-	edge1 := []*node{curNode, n}
-
-	for _, cl := range nodes {
-		for _, link := range cl.nodes {
-			edge2 := []*node{cl, link}
-
-			if intNode := findIntersect(edge1, edge2); intNode != nil {
-				intNode.isInside = true
-				intNode.id = idGen.Next()
-				relink(intNode, curNode, n, cl, link)
-				return intNode
-			}
-
-		}
+// orientRing returns poly running counter-clockwise when ccw is set and
+// clockwise otherwise, reversing a copy when needed
+func orientRing(poly Polygon, ccw bool) Polygon {
+	if (signedArea(poly) >= 0) == ccw {
+		return poly
 	}
-
-	return nil
+	reversed := make(Polygon, len(poly))
+	for i, c := range poly {
+		reversed[len(poly)-1-i] = c
+	}
+	return reversed
 }
 
 func makeShapeWithID(poly Polygon, isTarget bool, idGen *idGenerator) []*node {
@@ -381,7 +353,7 @@ func makeShapeWithID(poly Polygon, isTarget bool, idGen *idGenerator) []*node {
 func triangulate(nodes []*node) (Polygons, error) {
 	ln := len(nodes)
 	if ln < 3 {
-		return nil, fmt.Errorf("triangulate: not enough edges (need at least 3, got %d)", ln)
+		return nil, &DegenerateInputError{Name: "triangulated ring", Polygon: nodeCoords(nodes), Min: 3}
 	}
 
 	triangles := make([]Polygon, 0, ln-2)
@@ -414,14 +386,14 @@ func edges(nodes []*node) [][]*node {
 	return list
 }
 
-func coordsEqual(a, b Coord) bool {
-	return math.Abs(a[0]-b[0]) < eps && math.Abs(a[1]-b[1]) < eps
+func (tol tolerance) coordsEqual(a, b Coord) bool {
+	return math.Abs(a[0]-b[0]) < tol.eps && math.Abs(a[1]-b[1]) < tol.eps
 }
 
-func mergeCoincidentNodes(targetNodes, clipNodes []*node) {
+func (tol tolerance) mergeCoincidentNodes(targetNodes, clipNodes []*node) {
 	for _, tn := range targetNodes {
 		for i, cn := range clipNodes {
-			if coordsEqual(tn.coord, cn.coord) {
+			if tol.coordsEqual(tn.coord, cn.coord) {
 				// Transfer neighbors and inside status
 				for _, neighbor := range cn.nodes {
 					neighbor.remove(cn)
@@ -444,18 +416,18 @@ func nodeContains(nodes []*node, target *node) bool {
 	return false
 }
 
-func intersectPointOnEdge(targetNodes []*node, clip [][]*node) ([]*node, [][]*node) {
+func (tol tolerance) intersectPointOnEdge(targetNodes []*node, clip [][]*node) ([]*node, [][]*node) {
 	for _, tn := range targetNodes {
 		for i := 0; i < len(clip); i++ {
 			edge := clip[i]
 			a, b := edge[0], edge[1]
 
-			if coordsEqual(tn.coord, a.coord) || coordsEqual(tn.coord, b.coord) {
+			if tol.coordsEqual(tn.coord, a.coord) || tol.coordsEqual(tn.coord, b.coord) {
 				tn.isInside = true
 				continue
 			}
 
-			if pointOnEdge(tn.coord[0], tn.coord[1], a.coord[0], a.coord[1], b.coord[0], b.coord[1]) {
+			if tol.snapsTo(tn.coord, a.coord, b.coord) {
 				tn.isInside = true
 				clip[i] = []*node{a, tn}
 				clip = append(clip, []*node{tn, b})
@@ -472,11 +444,18 @@ func intersectPointOnEdge(targetNodes []*node, clip [][]*node) ([]*node, [][]*no
 	return targetNodes, clip
 }
 
-func intersect(target, clip [][]*node, id *idGenerator) ([][]*node, [][]*node) {
+// intersect splits the edges of target and clip where they cross each
+// other. It stops early, leaving the edges half split, once stop does.
+func (tol tolerance) intersect(target, clip [][]*node, id *idGenerator, stop *canceller) ([][]*node, [][]*node) {
 	for i := 0; i < len(clip); i++ {
+		if stop.tick(len(target)) {
+			return target, clip
+		}
 		for j := 0; j < len(target); j++ {
 			e1, e2 := clip[i], target[j]
-			intNode := findIntersect(e1, e2)
+			// A crossing takes its height from the target edge, as
+			// ZInterpolate documents
+			intNode := tol.findIntersect(e2, e1)
 			if intNode == nil {
 				continue
 			}
@@ -510,29 +489,67 @@ func intersect(target, clip [][]*node, id *idGenerator) ([][]*node, [][]*node) {
 	return target, clip
 }
 
+// intersectSelf splits the edges of a single ring where they cross each
+// other, stopping early like intersect.
+func (tol tolerance) intersectSelf(ring [][]*node, id *idGenerator, stop *canceller) [][]*node {
+	for i := 0; i < len(ring); i++ {
+		if stop.tick(len(ring) - i) {
+			return ring
+		}
+		for j := i + 1; j < len(ring); j++ {
+			e1, e2 := ring[i], ring[j]
+			intNode := tol.findIntersect(e1, e2)
+			if intNode == nil {
+				continue
+			}
+
+			intNode.id = id.Next()
+
+			u1, v1 := e1[0], e1[1]
+			u2, v2 := e2[0], e2[1]
+
+			u1.remove(v1)
+			v1.remove(u1)
+			u2.remove(v2)
+			v2.remove(u2)
+
+			u1.add(intNode)
+			intNode.add(v1)
+			u2.add(intNode)
+			intNode.add(v2)
+
+			ring[i] = []*node{u1, intNode}
+			ring[j] = []*node{u2, intNode}
+			ring = append(ring, []*node{intNode, v1}, []*node{intNode, v2})
+		}
+	}
+	return ring
+}
+
 func newClip(tri, clip Polygon) (Polygons, error) {
 
 	idGen := &idGenerator{}
+	tol := scaledTolerance(Polygons{tri, clip})
 
 	targetNodes := makeShapeWithID(tri, true, idGen)
 	clipNodes := makeShapeWithID(clip, false, idGen)
 
-	areAllInside := setIsInside(targetNodes, clipNodes)
+	areAllInside := tol.setIsInside(targetNodes, clipNodes)
 	if areAllInside {
 		return triangulate(targetNodes)
 	}
-	areAllInside = setIsInside(clipNodes, targetNodes)
+	areAllInside = tol.setIsInside(clipNodes, targetNodes)
 	if areAllInside {
 		return triangulate(clipNodes)
 	}
 
-	mergeCoincidentNodes(targetNodes, clipNodes)
+	tol.mergeCoincidentNodes(targetNodes, clipNodes)
 
 	clipEdges := edges(clipNodes)
-	targetNodes, clipEdges = intersectPointOnEdge(targetNodes, clipEdges)
+	targetNodes, clipEdges = tol.intersectPointOnEdge(targetNodes, clipEdges)
 
 	targetEdges := edges(targetNodes)
-	targetEdges, clipEdges = intersect(targetEdges, clipEdges, idGen)
+	targetEdges, clipEdges = tol.intersect(targetEdges, clipEdges, idGen, nil)
 
 	allEdges := make([][]*node, 0, len(targetEdges)+len(clipEdges))
 	allEdges = append(allEdges, targetEdges...)
@@ -559,8 +576,16 @@ func newClip(tri, clip Polygon) (Polygons, error) {
 	prev := allRelevant[0][0]
 	current := allRelevant[0][1]
 
-	// todo add safety max len(target)*len(clip)*2 iterations
+	// A closed loop visits every relevant edge once, so any longer walk is
+	// going round in circles
+	limit := len(allRelevant)
 	for current != loop[0] {
+		if current == nil {
+			return nil, &LoopNotClosedError{Target: Polygons{tri}, Clip: Polygons{clip}, Loop: nodeCoords(loop)}
+		}
+		if len(loop) >= limit {
+			return nil, &IterationLimitError{Target: Polygons{tri}, Clip: Polygons{clip}, Limit: limit, Loop: nodeCoords(loop)}
+		}
 		loop = append(loop, current)
 
 		// Find next node (the neighbor that isn't prev)
@@ -578,21 +603,23 @@ func newClip(tri, clip Polygon) (Polygons, error) {
 	}
 
 	if len(loop) != len(allRelevant) {
-		log.Fatalf("loop incomplete: visited %d nodes but have %d edges", len(loop), len(allRelevant))
+		return nil, &LoopNotClosedError{Target: Polygons{tri}, Clip: Polygons{clip}, Loop: nodeCoords(loop)}
 	}
 
 	return triangulate(loop)
 
 }
 
-func isInsideNodes(n1 *node, n2 []*node) bool {
+// isInsideNodes checks if n1 lies inside or on the ring n2 under the given
+// fill rule
+func (tol tolerance) isInsideNodes(n1 *node, n2 []*node, rule FillRule) bool {
 	if n1 == nil || len(n2) < 3 {
 		return false
 	}
 	px := float64(n1.coord[0])
 	py := float64(n1.coord[1])
 	inside := false
-	const eps = 1e-9
+	winding := 0
 	prev := n2[len(n2)-1]
 	for _, curr := range n2 {
 		if curr == nil || prev == nil {
@@ -610,50 +637,63 @@ func isInsideNodes(n1 *node, n2 []*node) bool {
 			return true
 		}
 
-		if pointOnEdge(px, py, x1, y1, x2, y2) {
+		if tol.pointOnEdge(px, py, x1, y1, x2, y2) {
 			return true
 		}
 
-		if (math.Abs(px-x1) < eps && math.Abs(py-y1) < eps) ||
-			(math.Abs(px-x2) < eps && math.Abs(py-y2) < eps) {
+		if tol.coordsEqual(n1.coord, prev.coord) || tol.coordsEqual(n1.coord, curr.coord) {
 			return true
 		}
 
-		if math.Abs(y1-y2) < eps {
-			prev = curr
-			continue
-		}
-		if (y1 > py) != (y2 > py) {
-			xInt := (x2-x1)*(py-y1)/(y2-y1) + x1
-			if math.Abs(px-xInt) < eps {
-				return true
-			}
-			if px < xInt {
-				inside = !inside
-			}
+		if c := crossing(n1.coord, prev.coord, curr.coord); c != 0 {
+			inside = !inside
+			winding += c
 		}
 		prev = curr
 	}
-	return inside
+	if rule == EvenOdd {
+		return inside
+	}
+	return rule.contains(winding)
 }
 
-func findIntersect(edge1, edge2 []*node) *node {
+func (tol tolerance) findIntersect(edge1, edge2 []*node) *node {
 	a1, a2 := edge1[0].coord, edge1[1].coord
 	b1, b2 := edge2[0].coord, edge2[1].coord
 
+	// Use a fixed direction per edge so that an edge shared by neighbouring
+	// faces yields bit-identical intersections from both sides
+	if lessCoord(a2, a1) {
+		a1, a2 = a2, a1
+	}
+	if lessCoord(b2, b1) {
+		b1, b2 = b2, b1
+	}
+
 	ax, ay := a2[0]-a1[0], a2[1]-a1[1]
 	bx, by := b2[0]-b1[0], b2[1]-b1[1]
 	den := ax*by - ay*bx
 
-	if math.Abs(den) < denEps {
+	// Whether the edges cross is decided by exact orientations, so that
+	// nearly parallel edges are treated the same from both sides. Parallel
+	// edges have no single crossing. Where they overlap, the end points of
+	// the overlap are vertices lying on the other edge, which
+	// intersectPointOnEdge splits at; buildOverlayEdges then merges the
+	// coinciding pieces into one edge.
+	if !properCrossing(a1, a2, b1, b2) || den == 0 {
 		return nil
 	}
+	if tol.integer {
+		return intersectExact(a1, a2, b1, b2)
+	}
 
 	cx, cy := b1[0]-a1[0], b1[1]-a1[1]
 	t := (cx*by - cy*bx) / den
 	u := (cx*ay - cy*ax) / den
 
-	if t < eps || t > 1-eps || u < eps || u > 1-eps {
+	// Crossings within eps of an end point are left to intersectPointOnEdge
+	la, lb := math.Hypot(ax, ay), math.Hypot(bx, by)
+	if t*la < tol.eps || (1-t)*la < tol.eps || u*lb < tol.eps || (1-u)*lb < tol.eps {
 		return nil
 	}
 
@@ -663,11 +703,55 @@ func findIntersect(edge1, edge2 []*node) *node {
 	}
 }
 
-func pointOnEdge(px, py, x1, y1, x2, y2 float64) bool {
-	if px < math.Min(x1, x2)-eps || px > math.Max(x1, x2)+eps ||
-		py < math.Min(y1, y2)-eps || py > math.Max(y1, y2)+eps {
+func lessCoord(a, b Coord) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// snapsTo checks if the edge a-b has to be split at p. In integer mode this
+// is the case whenever the edge passes through the unit square around p, so
+// that vertices and rounded crossings never end up right next to an edge.
+func (tol tolerance) snapsTo(p, a, b Coord) bool {
+	if tol.integer {
+		return edgeHitsPixel(a, b, p)
+	}
+	return tol.pointOnEdge(p[0], p[1], a[0], a[1], b[0], b[1])
+}
+
+func (tol tolerance) pointOnEdge(px, py, x1, y1, x2, y2 float64) bool {
+	if px < math.Min(x1, x2)-tol.eps || px > math.Max(x1, x2)+tol.eps ||
+		py < math.Min(y1, y2)-tol.eps || py > math.Max(y1, y2)+tol.eps {
 		return false
 	}
+	if tol.integer {
+		return orient(Coord{x1, y1}, Coord{x2, y2}, Coord{px, py}) == 0
+	}
+	// The cross product is the distance from the line times the edge length.
+	// A point edge has no line, but p already lies within eps of it.
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 {
+		return true
+	}
 	cross := (x2-x1)*(py-y1) - (y2-y1)*(px-x1)
-	return math.Abs(cross) < eps
+	return math.Abs(cross) < tol.eps*length
+}
+
+// negligible checks if ring encloses no more area than a sliver as wide as
+// the tolerance along its perimeter, so that the test does not depend on the
+// scale of the coordinates. On the integer grid every area counts.
+func (tol tolerance) negligible(ring Polygon) bool {
+	area := math.Abs(signedArea(ring))
+	if tol.integer {
+		return area == 0
+	}
+	var perimeter float64
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		perimeter += math.Hypot(b[0]-a[0], b[1]-a[1])
+	}
+	return area < tol.eps*perimeter/2
 }
@@ -72,7 +72,7 @@ func TestFindIntersectInterpolatesZ(t *testing.T) {
 		{coord: Coord{5, 5, 0}},
 	}
 
-	intersection := findIntersect(edge1, edge2)
+	intersection := defaultTolerance.findIntersect(edge1, edge2)
 	if intersection == nil {
 		t.Fatalf("expected intersection, got nil")
 	}
@@ -191,6 +191,101 @@ func TestClipMeshFaces(t *testing.T) {
 	}
 }
 
+func TestClipConcaveMultiplePieces(t *testing.T) {
+	parcel := Polygon{{0, 0}, {6, 0}, {6, 6}, {4, 6}, {4, 2}, {2, 2}, {2, 6}, {0, 6}}
+	strip := Polygon{{-1, 3}, {7, 3}, {7, 5}, {-1, 5}}
+
+	triangles, err := Clip(parcel, strip)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+
+	var left, right float64
+	for _, tri := range triangles {
+		a := signedArea(tri)
+		if a <= 0 {
+			t.Fatalf("triangle %v does not keep the parcel winding", tri)
+		}
+		cx := (tri[0][0] + tri[1][0] + tri[2][0]) / 3
+		switch {
+		case cx < 2:
+			left += a
+		case cx > 4:
+			right += a
+		default:
+			t.Fatalf("triangle %v lies in the gap of the U", tri)
+		}
+	}
+	if math.Abs(left-4) > 1e-9 || math.Abs(right-4) > 1e-9 {
+		t.Fatalf("piece areas = %.3f and %.3f, want 4 and 4", left, right)
+	}
+
+	filename := filepath.Join("test_output", "clip_concave_pieces.png")
+	if err := saveTriangleCropPNG(filename, strip, parcel, triangles); err != nil {
+		t.Fatalf("save png: %v", err)
+	}
+}
+
+func TestClipDisjointReturnsNothing(t *testing.T) {
+	clip := Polygon{{0, -1}, {3, -1}, {3, 3}, {0, 3}, {0, 2}, {2, 2}, {2, 0}, {0, 0}}
+
+	tests := []struct {
+		name   string
+		target Polygon
+	}{
+		{name: "far_away", target: Polygon{{5, 5}, {6, 5}, {6, 6}}},
+		{name: "inside_notch", target: Polygon{{1, 0.5}, {1.5, 0.5}, {1.5, 1.5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triangles, err := Clip(tt.target, clip)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if len(triangles) != 0 {
+				t.Fatalf("expected no triangles for disjoint polygons, got %v", triangles)
+			}
+		})
+	}
+}
+
+func TestClipOutline(t *testing.T) {
+	// clockwise parcel, so the outlines must not follow the target winding
+	parcel := orientRing(Polygon{{0, 0}, {6, 0}, {6, 6}, {4, 6}, {4, 2}, {2, 2}, {2, 6}, {0, 6}}, false)
+	strip := Polygon{{-1, 3}, {7, 3}, {7, 5}, {-1, 5}}
+
+	rings, err := ClipOutline(parcel, strip)
+	if err != nil {
+		t.Fatalf("clip outline: %v", err)
+	}
+	if len(rings) != 2 {
+		t.Fatalf("got %d rings, want 2: %v", len(rings), rings)
+	}
+	for _, r := range rings {
+		if len(r) != 4 {
+			t.Fatalf("ring %v is not a plain rectangle", r)
+		}
+		if a := signedArea(r); math.Abs(a-4) > 1e-9 {
+			t.Fatalf("ring %v has signed area %.3f, want 4", r, a)
+		}
+	}
+
+	var area float64
+	for _, r := range Regions(rings) {
+		tris, err := TriangulateRegion(r)
+		if err != nil {
+			t.Fatalf("triangulate: %v", err)
+		}
+		for _, tri := range tris {
+			area += signedArea(tri)
+		}
+	}
+	if math.Abs(area-8) > 1e-9 {
+		t.Fatalf("triangulated outline area = %.3f, want 8", area)
+	}
+}
+
 func Test_meshWithReturnNewMesh(t *testing.T) {
 	vertices := []Coord{
 		{0, 0, 0},
@@ -222,13 +317,15 @@ func Test_meshWithReturnNewMesh(t *testing.T) {
 		t.Fatalf("expected 4 faces after clipping, got %d", len(newFaces))
 	}
 
+	// Crossings lie on the face edges and take their height from them, so
+	// (4, 3) gets the height 4 of the edge x = 4
 	expectedVerts := map[Coord]struct{}{
-		{2, 0, 2}:   {},
-		{4, 0, 4}:   {},
-		{4, 3, 1.5}: {},
-		{3, 3, 3}:   {},
-		{2, 2, 2}:   {},
-		{2, 3, 0}:   {},
+		{2, 0, 2}: {},
+		{4, 0, 4}: {},
+		{4, 3, 4}: {},
+		{3, 3, 3}: {},
+		{2, 2, 2}: {},
+		{2, 3, 0}: {},
 	}
 	for _, v := range newVerts {
 		if _, ok := expectedVerts[v]; !ok {
@@ -322,9 +419,9 @@ func TestIntersect(t *testing.T) {
 				{coord: tt.b1},
 				{coord: tt.b2},
 			}
-			intersect := findIntersect(edge1, edge2)
+			intersect := defaultTolerance.findIntersect(edge1, edge2)
 			if got := intersect != nil; got != tt.want {
-				t.Fatalf("findIntersect(%v, %v, %v, %v) != nil = %v, want %v", tt.a1, tt.a2, tt.b1, tt.b2, got, tt.want)
+				t.Fatalf("defaultTolerance.findIntersect(%v, %v, %v, %v) != nil = %v, want %v", tt.a1, tt.a2, tt.b1, tt.b2, got, tt.want)
 			}
 		})
 	}
@@ -363,7 +460,7 @@ func TestPointOfSegemtn(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := pointOnEdge(tt.p[0], tt.p[1], tt.b1[0], tt.b1[1], tt.b2[0], tt.b2[1]); got != tt.want {
+			if got := defaultTolerance.pointOnEdge(tt.p[0], tt.p[1], tt.b1[0], tt.b1[1], tt.b2[0], tt.b2[1]); got != tt.want {
 				t.Fatalf("segmentsIntersect(%v, %v, %v, %v) = %v, want %v", tt.p, tt.a2, tt.b1, tt.b2, got, tt.want)
 			}
 		})
@@ -400,20 +497,20 @@ func Test_newClip(t *testing.T) {
 	targetNodes := makeShapeWithID(tri, true, idGen)
 	clipNodes := makeShapeWithID(clip, false, idGen)
 
-	areAllInside := setIsInside(targetNodes, clipNodes)
+	areAllInside := defaultTolerance.setIsInside(targetNodes, clipNodes)
 	if areAllInside {
 		// triangulte
 	}
-	areAllInside = setIsInside(clipNodes, targetNodes)
+	areAllInside = defaultTolerance.setIsInside(clipNodes, targetNodes)
 	if areAllInside {
 		// triangulte
 	}
 
 	clipEdges := edges(clipNodes)
-	targetNodes, clipEdges = intersectPointOnEdge(targetNodes, clipEdges)
+	targetNodes, clipEdges = defaultTolerance.intersectPointOnEdge(targetNodes, clipEdges)
 
 	targetEdges := edges(targetNodes)
-	targetEdges, clipEdges = intersect(targetEdges, clipEdges, idGen)
+	targetEdges, clipEdges = defaultTolerance.intersect(targetEdges, clipEdges, idGen, nil)
 
 	allEdges := make([][]*node, 0, len(targetEdges)+len(clipEdges))
 	allEdges = append(allEdges, targetEdges...)
@@ -537,3 +634,87 @@ func Test_edges(t *testing.T) {
 	}
 
 }
+
+func TestSegmentsIntersectCollinear(t *testing.T) {
+	tests := []struct {
+		name           string
+		a1, a2, b1, b2 Coord
+		want           bool
+	}{
+		{name: "shared_edge", a1: Coord{0, 0}, a2: Coord{2, 0}, b1: Coord{2, 0}, b2: Coord{0, 0}, want: true},
+		{name: "partial_overlap", a1: Coord{0, 0}, a2: Coord{2, 0}, b1: Coord{1, 0}, b2: Coord{3, 0}, want: true},
+		{name: "contained_vertical", a1: Coord{0, 0}, a2: Coord{0, 4}, b1: Coord{0, 1}, b2: Coord{0, 2}, want: true},
+		{name: "touching_end_points", a1: Coord{0, 0}, a2: Coord{2, 0}, b1: Coord{2, 0}, b2: Coord{3, 0}, want: false},
+		{name: "parallel_apart", a1: Coord{0, 0}, a2: Coord{2, 0}, b1: Coord{0, 1}, b2: Coord{2, 1}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := segmentsIntersect(tt.a1, tt.a2, tt.b1, tt.b2); got != tt.want {
+				t.Fatalf("segmentsIntersect(%v, %v, %v, %v) = %v, want %v", tt.a1, tt.a2, tt.b1, tt.b2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClipMeshGridAligned(t *testing.T) {
+	// a 4x4 grid of unit cells, clipped along its own grid lines
+	var vertices []Coord
+	var faces [][3]int
+	for y := 0.0; y < 4; y++ {
+		for x := 0.0; x < 4; x++ {
+			i := len(vertices)
+			vertices = append(vertices, Coord{x, y}, Coord{x + 1, y}, Coord{x + 1, y + 1}, Coord{x, y + 1})
+			faces = append(faces, [3]int{i, i + 1, i + 2}, [3]int{i, i + 2, i + 3})
+		}
+	}
+
+	tests := []struct {
+		name string
+		clip Polygon
+		area float64
+	}{
+		{name: "on_grid_lines", clip: square(1, 1, 2), area: 4},
+		{name: "partly_on_grid_lines", clip: Polygon{{1, 0.5}, {3, 0.5}, {3, 2}, {1, 2}}, area: 3},
+		{name: "along_diagonals", clip: Polygon{{0, 0}, {4, 4}, {0, 4}}, area: 8},
+		{name: "mesh_outline", clip: square(0, 0, 4), area: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newVerts, newFaces, err := ClipMesh(vertices, faces, tt.clip)
+			if err != nil {
+				t.Fatalf("clip mesh: %v", err)
+			}
+			var area float64
+			for _, f := range newFaces {
+				area += signedArea(Polygon{newVerts[f[0]], newVerts[f[1]], newVerts[f[2]]})
+			}
+			if math.Abs(area-tt.area) > 1e-9 {
+				t.Fatalf("clipped mesh area = %.3f, want %.3f", area, tt.area)
+			}
+		})
+	}
+}
+
+func TestClipMeshSliverFace(t *testing.T) {
+	// the last face has no area: its corners lie on the diagonal the other
+	// two faces share
+	vertices := []Coord{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {2, 2}}
+	faces := [][3]int{{0, 1, 2}, {0, 2, 3}, {0, 4, 2}}
+	// an L-shape whose edges cross the diagonal
+	clip := Polygon{{1, -1}, {5, -1}, {5, 5}, {3, 5}, {3, 1}, {1, 1}}
+
+	newVerts, newFaces, err := ClipMesh(vertices, faces, clip)
+	if err != nil {
+		t.Fatalf("clip mesh: %v", err)
+	}
+	var area float64
+	for _, f := range newFaces {
+		area += math.Abs(signedArea(Polygon{newVerts[f[0]], newVerts[f[1]], newVerts[f[2]]}))
+	}
+	// the L-shape covers [1,4]x[0,1] and [3,4]x[1,4] of the mesh
+	if math.Abs(area-6) > 1e-9 {
+		t.Fatalf("clipped mesh area = %.3f, want 6.000", area)
+	}
+}
@@ -1,13 +1,45 @@
 package clippoly
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // ClipMesh clips all faces of a mesh against the provided clip polygon.
 // The returned vertices and faces describe the clipped mesh using shared vertices.
+// Faces without area, such as those with collinear corners, are dropped. A
+// face referring to a vertex that does not exist fails with a FaceIndexError.
 func ClipMesh(vertices []Coord, faces [][3]int, clip Polygon) ([]Coord, [][3]int, error) {
-	if len(faces) == 0 || len(vertices) == 0 {
+	return ClipMeshRegion(vertices, faces, Region{Exterior: clip})
+}
+
+// ClipMeshContext is ClipMesh that gives up with the context error once ctx is
+// cancelled or its deadline passes. Faces are clipped one by one, so a large
+// mesh stops between faces.
+func ClipMeshContext(ctx context.Context, vertices []Coord, faces [][3]int, clip Polygon) ([]Coord, [][3]int, error) {
+	return clipMeshRegion(ctx, vertices, faces, Region{Exterior: clip})
+}
+
+// ClipMeshRegion clips all faces of a mesh against a clip region, dropping the
+// parts of faces that fall inside its holes.
+func ClipMeshRegion(vertices []Coord, faces [][3]int, clip Region) ([]Coord, [][3]int, error) {
+	return clipMeshRegion(context.Background(), vertices, faces, clip)
+}
+
+func clipMeshRegion(ctx context.Context, vertices []Coord, faces [][3]int, clip Region) ([]Coord, [][3]int, error) {
+	return clipMeshFaces(ctx, vertices, faces, clip, clip.Exterior)
+}
+
+// clipMeshFaces is clipMeshRegion where corners, if not nil, holds the clip
+// corners that result vertices take over as they are. Without them, vertices
+// at the corners of a rectangular clip take their height from the face.
+func clipMeshFaces(ctx context.Context, vertices []Coord, faces [][3]int, clip Region, corners Polygon) ([]Coord, [][3]int, error) {
+	if len(faces) == 0 {
 		return nil, nil, nil
 	}
+	if err := clip.validate("clip"); err != nil {
+		return nil, nil, err
+	}
 
 	vertexIndex := make(map[Coord]int, len(vertices))
 	clippedVerts := make([]Coord, 0, len(vertices))
@@ -23,19 +55,55 @@ func ClipMesh(vertices []Coord, faces [][3]int, clip Polygon) ([]Coord, [][3]int
 		return idx
 	}
 
-	for _, face := range faces {
+	// One tolerance for the whole mesh, so that neighbouring faces make the
+	// same decisions along their shared edges
+	tol := scaledTolerance(Polygons{vertices}, clip.rings())
+
+	// Convex clips, such as tiles, stream every face through the
+	// Sutherland–Hodgman clipper and fan the convex piece that remains
+	var convex interface{ clip(Polygon) Polygon }
+	if r, ok := rectBounds(clip.Exterior); ok && len(clip.Holes) == 0 {
+		convex = newRectClipper(r, corners, tol)
+	} else if tol.convexFastPath(clip.Exterior, clip, EvenOdd) {
+		convex = newConvexClipper(clip.Exterior, tol)
+	}
+
+	for fi, face := range faces {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if err := validateFace(vertices, fi, face); err != nil {
+			return nil, nil, err
+		}
 		poly := Polygon{
 			vertices[face[0]],
 			vertices[face[1]],
 			vertices[face[2]],
 		}
+		if tol.negligible(poly) {
+			// Sliver faces cover nothing, so nothing of them is kept
+			continue
+		}
 
-		clipped, err := Clip(poly, clip)
-		if err != nil {
-			fmt.Println("error: ", err, poly, clip)
-			// return nil, nil, err
+		if convex != nil {
+			piece := convex.clip(poly)
+			for i := 1; i+1 < len(piece); i++ {
+				clippedFaces = append(clippedFaces, [3]int{
+					addVertex(piece[0]),
+					addVertex(piece[i]),
+					addVertex(piece[i+1]),
+				})
+			}
 			continue
 		}
+
+		clipped, err := tol.clipPolygon(ctx, poly, clip, ClipOptions{})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("face %d: %w", fi, err)
+		}
 		if clipped == nil {
 			continue
 		}
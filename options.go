@@ -0,0 +1,283 @@
+package clippoly
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// OutputMode selects the form in which clipping results are returned.
+type OutputMode int
+
+const (
+	// OutputTriangles triangulates every piece of the result.
+	OutputTriangles OutputMode = iota
+	// OutputRings returns the outline of every piece followed by its holes.
+	OutputRings
+)
+
+// Orientation selects the winding of the returned triangles or rings.
+type Orientation int
+
+const (
+	// FollowTarget keeps the winding of the target polygon. Holes run the
+	// other way.
+	FollowTarget Orientation = iota
+	// CounterClockwise returns counter-clockwise triangles and exteriors with
+	// clockwise holes.
+	CounterClockwise
+	// Clockwise returns clockwise triangles and exteriors with
+	// counter-clockwise holes.
+	Clockwise
+)
+
+// ZMode selects how the heights of the returned vertices are computed.
+type ZMode int
+
+const (
+	// ZInterpolate keeps the height of input vertices and interpolates new
+	// vertices along the target edge they lie on.
+	ZInterpolate ZMode = iota
+	// ZTargetPlane puts every vertex on the plane through the target, so
+	// clip vertices inside a mesh face take the height of the face.
+	ZTargetPlane
+	// ZDrop sets every height to zero.
+	ZDrop
+)
+
+// Backend selects the algorithm that nodes the edges of the inputs and
+// classifies the pieces. Both split the edges at the same vertices and
+// crossings, but compute the crossings between different pieces of the
+// edges, so their coordinates may differ by rounding.
+type Backend int
+
+const (
+	// AutoBackend uses the graph backend for small inputs and the sweep
+	// for inputs with many vertices.
+	AutoBackend Backend = iota
+	// GraphBackend compares every edge with every other edge, which is
+	// O(n·m) but has little overhead.
+	GraphBackend
+	// SweepBackend sweeps a line across the inputs and keeps the edges it
+	// cuts in an interval tree, so that it only compares edges whose
+	// bounding boxes touch. For n vertices and k such pairs of edges it
+	// takes O((n+k) log n), and k is usually close to n.
+	SweepBackend
+)
+
+func (b Backend) validate() error {
+	if b < AutoBackend || b > SweepBackend {
+		return fmt.Errorf("unknown backend %d", b)
+	}
+	return nil
+}
+
+// ClipOptions configures ClipWithOptions. The zero value behaves like Clip.
+type ClipOptions struct {
+	// Tolerance is the distance below which points are considered equal or
+	// on an edge. Zero derives it from the inputs as 1e-10 times their
+	// largest absolute coordinate.
+	Tolerance float64
+	// FillRule decides which parts of self-overlapping inputs are inside.
+	FillRule FillRule
+	// Simplify splits self-intersecting inputs into simple rings under
+	// FillRule, as SimplifyPolygon does, before clipping them.
+	Simplify bool
+	// Output selects triangles or rings.
+	Output OutputMode
+	// Orientation selects the winding of the result.
+	Orientation Orientation
+	// Z selects how vertex heights are computed.
+	Z ZMode
+	// Grid, when positive, snap-rounds all vertices to multiples of Grid.
+	// Crossings are computed exactly and rounded, and edges passing within
+	// half a grid step of a vertex or rounded crossing are routed through
+	// it, so the result has no near-duplicate vertices and its edges only
+	// meet at vertices. Tolerance is not used in this mode.
+	Grid float64
+	// Backend selects the noding algorithm.
+	Backend Backend
+}
+
+// tolerance returns the noding tolerance for o when clipping rings
+func (o ClipOptions) tolerance(rings ...Polygons) tolerance {
+	tol := tolerance{eps: o.Tolerance}
+	if o.Tolerance == 0 {
+		tol = scaledTolerance(rings...)
+	}
+	tol.backend = o.Backend
+	return tol
+}
+
+func (o ClipOptions) validate() error {
+	if o.Tolerance < 0 || math.IsNaN(o.Tolerance) || math.IsInf(o.Tolerance, 0) {
+		return fmt.Errorf("tolerance must be a positive distance or zero, got %g", o.Tolerance)
+	}
+	if o.Grid < 0 || math.IsNaN(o.Grid) || math.IsInf(o.Grid, 0) {
+		return fmt.Errorf("grid must be a positive step or zero, got %g", o.Grid)
+	}
+	if err := o.FillRule.validate(); err != nil {
+		return err
+	}
+	if o.Output < OutputTriangles || o.Output > OutputRings {
+		return fmt.Errorf("unknown output mode %d", o.Output)
+	}
+	if o.Orientation < FollowTarget || o.Orientation > Clockwise {
+		return fmt.Errorf("unknown orientation %d", o.Orientation)
+	}
+	if o.Z < ZInterpolate || o.Z > ZDrop {
+		return fmt.Errorf("unknown z mode %d", o.Z)
+	}
+	return o.Backend.validate()
+}
+
+// ClipWithOptions returns the intersection of target and clip, configured by
+// opts.
+func ClipWithOptions(target, clip Polygon, opts ClipOptions) (Polygons, error) {
+	return ClipContext(context.Background(), target, clip, opts)
+}
+
+// ClipContext is ClipWithOptions that gives up with the context error once
+// ctx is cancelled or its deadline passes.
+func ClipContext(ctx context.Context, target, clip Polygon, opts ClipOptions) (Polygons, error) {
+	if err := validatePolygon("target polygon", target); err != nil {
+		return nil, err
+	}
+	if err := validatePolygon("clip polygon", clip); err != nil {
+		return nil, err
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Grid > 0 {
+		return clipOnGrid(ctx, target, clip, opts)
+	}
+	return opts.tolerance(Polygons{target, clip}).clipPolygon(ctx, target, Region{Exterior: clip}, opts)
+}
+
+// clipPolygon is the shared path behind ClipContext, ClipInt and
+// ClipMeshRegion.
+func (tol tolerance) clipPolygon(ctx context.Context, target Polygon, clip Region, opts ClipOptions) (Polygons, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var regions []Region
+	if opts.Simplify {
+		var err error
+		if regions, err = tol.intersectSimplified(ctx, target, clip, opts.FillRule); err != nil {
+			return nil, err
+		}
+	} else if tol.convexFastPath(target, clip, opts.FillRule) {
+		// Two convex rings meet in at most one convex piece
+		if ring := newConvexClipper(clip.Exterior, tol).clip(target); ring != nil {
+			regions = []Region{{Exterior: orientRing(append(Polygon(nil), ring...), true)}}
+		}
+	} else {
+		var err error
+		if regions, err = tol.intersectRegions(ctx, Region{Exterior: target}, clip, opts.FillRule); err != nil {
+			return nil, err
+		}
+	}
+
+	ccw := true
+	switch opts.Orientation {
+	case FollowTarget:
+		ccw = signedArea(target) >= 0
+	case Clockwise:
+		ccw = false
+	}
+
+	var result Polygons
+	for _, r := range regions {
+		if opts.Output == OutputRings {
+			result = append(result, orientRing(r.Exterior, ccw))
+			for _, h := range r.Holes {
+				result = append(result, orientRing(h, !ccw))
+			}
+			continue
+		}
+		tris, err := TriangulateRegion(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, tri := range tris {
+			result = append(result, orientRing(tri, ccw))
+		}
+	}
+
+	switch opts.Z {
+	case ZTargetPlane:
+		if p, ok := newellPlane(target); ok {
+			result = withHeights(result, p.z)
+		}
+	case ZDrop:
+		result = withHeights(result, func(x, y float64) float64 { return 0 })
+	}
+
+	return result, nil
+}
+
+// intersectSimplified splits target and clip into simple rings under rule and
+// intersects them.
+func (tol tolerance) intersectSimplified(ctx context.Context, target Polygon, clip Region, rule FillRule) ([]Region, error) {
+	t, err := tol.overlay(ctx, Polygons{target}, nil, opUnion, rule)
+	if err != nil {
+		return nil, err
+	}
+	c, err := tol.overlay(ctx, clip.rings(), nil, opUnion, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// The simple rings only overlap where a hole lies in its exterior
+	rings, err := tol.overlay(ctx, t, c, opIntersection, EvenOdd)
+	if err != nil {
+		return nil, err
+	}
+	return tol.regions(rings), nil
+}
+
+// withHeights returns a copy of polys with every height replaced by z. The
+// rings may share their backing arrays with the input, so they are not
+// modified in place.
+func withHeights(polys Polygons, z func(x, y float64) float64) Polygons {
+	out := make(Polygons, len(polys))
+	for i, poly := range polys {
+		out[i] = make(Polygon, len(poly))
+		for j, c := range poly {
+			out[i][j] = Coord{c[0], c[1], z(c[0], c[1])}
+		}
+	}
+	return out
+}
+
+// plane is the plane through origin with the given normal
+type plane struct {
+	origin, normal Coord
+}
+
+// newellPlane fits a plane through poly using Newell's method. ok is false
+// when poly is degenerate or vertical in plan.
+func newellPlane(poly Polygon) (p plane, ok bool) {
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		p.normal[0] += (a[1] - b[1]) * (a[2] + b[2])
+		p.normal[1] += (a[2] - b[2]) * (a[0] + b[0])
+		p.normal[2] += (a[0] - b[0]) * (a[1] + b[1])
+		for k := range p.origin {
+			p.origin[k] += a[k] / float64(len(poly))
+		}
+	}
+	// Compared with the length of the normal, so that the test does not
+	// depend on the scale of the coordinates
+	n := p.normal
+	return p, math.Abs(n[2]) > relativeEps*math.Sqrt(n[0]*n[0]+n[1]*n[1]+n[2]*n[2])
+}
+
+// z returns the height of p above x, y
+func (p plane) z(x, y float64) float64 {
+	n, o := p.normal, p.origin
+	return o[2] - (n[0]*(x-o[0])+n[1]*(y-o[1]))/n[2]
+}
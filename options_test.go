@@ -0,0 +1,314 @@
+package clippoly
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestClipWithOptions(t *testing.T) {
+	// a clockwise face on the plane z = x
+	face := Polygon{{0, 0, 0}, {0, 4, 0}, {4, 4, 4}, {4, 0, 4}}
+	clip := Polygon{{1, 1, 9}, {3, 1, 9}, {3, 3, 9}, {1, 3, 9}}
+
+	tests := []struct {
+		name  string
+		opts  ClipOptions
+		count int
+		ccw   bool
+		z     func(c Coord) float64
+	}{
+		{name: "defaults", opts: ClipOptions{}, count: 2, ccw: false, z: func(Coord) float64 { return 9 }},
+		{name: "rings_ccw", opts: ClipOptions{Output: OutputRings, Orientation: CounterClockwise}, count: 1, ccw: true, z: func(Coord) float64 { return 9 }},
+		{name: "rings_cw", opts: ClipOptions{Output: OutputRings, Orientation: Clockwise}, count: 1, ccw: false, z: func(Coord) float64 { return 9 }},
+		{name: "target_plane", opts: ClipOptions{Z: ZTargetPlane}, count: 2, ccw: false, z: func(c Coord) float64 { return c[0] }},
+		{name: "drop_z", opts: ClipOptions{Z: ZDrop, Tolerance: 1e-6}, count: 2, ccw: false, z: func(Coord) float64 { return 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClipWithOptions(face, clip, tt.opts)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if len(got) != tt.count {
+				t.Fatalf("got %d polygons, want %d: %v", len(got), tt.count, got)
+			}
+			for _, p := range got {
+				if (signedArea(p) > 0) != tt.ccw {
+					t.Fatalf("polygon %v has the wrong winding", p)
+				}
+				for _, c := range p {
+					if want := tt.z(c); math.Abs(c[2]-want) > 1e-9 {
+						t.Fatalf("vertex %v has z %.3f, want %.3f", c, c[2], want)
+					}
+				}
+			}
+			if math.Abs(math.Abs(totalArea(got))-4) > 1e-9 {
+				t.Fatalf("area = %.3f, want 4", math.Abs(totalArea(got)))
+			}
+		})
+	}
+
+	if clip[0][2] != 9 {
+		t.Fatalf("clip input was modified: %v", clip)
+	}
+	for _, tolerance := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := ClipWithOptions(face, clip, ClipOptions{Tolerance: tolerance}); err == nil {
+			t.Fatalf("expected an error for tolerance %g", tolerance)
+		}
+	}
+}
+
+func TestClipWithOptionsTolerance(t *testing.T) {
+	// the clip corner stops 1e-5 short of the target corner, which only
+	// counts as the same point under a coarse tolerance
+	target := Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	clip := Polygon{{5, 5}, {9.99999, 5}, {9.99999, 9.99999}, {5, 9.99999}}
+
+	tests := []struct {
+		tolerance float64
+		want      Coord
+	}{
+		{tolerance: 0, want: Coord{9.99999, 9.99999}},
+		{tolerance: 1e-3, want: Coord{10, 10}},
+	}
+
+	for _, tt := range tests {
+		got, err := ClipWithOptions(target, clip, ClipOptions{Tolerance: tt.tolerance, Output: OutputRings})
+		if err != nil {
+			t.Fatalf("clip: %v", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("tolerance %g: got %d rings, want 1: %v", tt.tolerance, len(got), got)
+		}
+		found := false
+		for _, c := range got[0] {
+			found = found || c == tt.want
+		}
+		if !found {
+			t.Fatalf("tolerance %g: ring %v does not contain %v", tt.tolerance, got[0], tt.want)
+		}
+	}
+}
+
+func TestClipWithOptionsCrossingZ(t *testing.T) {
+	// one square lies on the plane z = x and the other floats at z = 9, so
+	// crossings must take the height of whichever is the target, with
+	// either backend
+	sloped := Polygon{{0, 0, 0}, {4, 0, 4}, {4, 4, 4}, {0, 4, 0}}
+	flat := Polygon{{2, -1, 9}, {5, -1, 9}, {5, 3, 9}, {2, 3, 9}}
+	crossings := []Coord{{2, 0}, {4, 3}}
+
+	tests := []struct {
+		name         string
+		target, clip Polygon
+		z            func(c Coord) float64
+	}{
+		{name: "sloped_target", target: sloped, clip: flat, z: func(c Coord) float64 { return c[0] }},
+		{name: "flat_target", target: flat, clip: sloped, z: func(Coord) float64 { return 9 }},
+	}
+
+	for _, tt := range tests {
+		for _, backend := range []Backend{GraphBackend, SweepBackend} {
+			got, err := ClipWithOptions(tt.target, tt.clip, ClipOptions{Output: OutputRings, Backend: backend})
+			if err != nil {
+				t.Fatalf("%s, backend %d: clip: %v", tt.name, backend, err)
+			}
+			if len(got) != 1 || len(got[0]) != 4 {
+				t.Fatalf("%s, backend %d: got %v, want one ring of 4 vertices", tt.name, backend, got)
+			}
+			for _, c := range got[0] {
+				for _, x := range crossings {
+					if c[0] == x[0] && c[1] == x[1] && math.Abs(c[2]-tt.z(c)) > 1e-9 {
+						t.Fatalf("%s, backend %d: crossing %v, want z %g", tt.name, backend, c, tt.z(c))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestClipContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	target := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	clip := Polygon{{2, 2}, {6, 2}, {6, 6}, {2, 6}}
+	if _, err := ClipContext(ctx, target, clip, ClipOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ClipContext error = %v, want context.Canceled", err)
+	}
+
+	vertices := []Coord{{0, 0}, {4, 0}, {4, 4}}
+	if _, _, err := ClipMeshContext(ctx, vertices, [][3]int{{0, 1, 2}}, clip); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ClipMeshContext error = %v, want context.Canceled", err)
+	}
+}
+
+func TestClipContextDeadline(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	target := wobblyCircle(rng, 0, 0, 10, 8000)
+
+	tests := []struct {
+		name string
+		clip Polygon
+	}{
+		{name: "crossing", clip: wobblyCircle(rng, 3, 1, 10, 8000)},
+		{name: "nested", clip: wobblyCircle(rng, 0, 0, 5, 8000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Comparing every edge pair takes well over a second
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := ClipContext(ctx, target, tt.clip, ClipOptions{Output: OutputRings, Backend: GraphBackend})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("ClipContext error = %v, want context.DeadlineExceeded", err)
+			}
+			if d := time.Since(start); d > 250*time.Millisecond {
+				t.Fatalf("ClipContext returned %v after the deadline passed", d-20*time.Millisecond)
+			}
+		})
+	}
+}
+
+// sameVertices checks if a and b hold the same vertices, in any order
+func sameVertices(a, b Polygon) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[Coord]int)
+	for _, v := range a {
+		seen[v]++
+	}
+	for _, v := range b {
+		if seen[v] == 0 {
+			return false
+		}
+		seen[v]--
+	}
+	return true
+}
+
+func TestClipWithOptionsGridRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	// star returns a simple polygon around (10, 10) with n vertices at
+	// random distances
+	star := func(n int) Polygon {
+		var p Polygon
+		for i := 0; i < n; i++ {
+			a, r := 2*math.Pi*float64(i)/float64(n), 1+9*rng.Float64()
+			p = append(p, Coord{10 + r*math.Cos(a), 10 + r*math.Sin(a)})
+		}
+		return p
+	}
+	// scribble returns n random vertices, which usually cross themselves
+	scribble := func(n int) Polygon {
+		var p Polygon
+		for i := 0; i < n; i++ {
+			p = append(p, Coord{20 * rng.Float64(), 20 * rng.Float64()})
+		}
+		return p
+	}
+
+	for _, backend := range []Backend{GraphBackend, SweepBackend} {
+		opts := ClipOptions{Grid: 1, Output: OutputRings, FillRule: NonZero, Backend: backend}
+		for i := 0; i < 100; i++ {
+			target, clip := star(20), star(20)
+			if i%2 == 1 {
+				target, clip = scribble(20), scribble(20)
+			}
+
+			got, err := ClipWithOptions(target, clip, opts)
+			if err != nil {
+				t.Fatalf("backend %d: clip %v by %v: %v", backend, target, clip, err)
+			}
+			if e, f, ok := crossingEdges(got); ok {
+				t.Fatalf("backend %d: clip %v by %v gave crossing edges %v and %v", backend, target, clip, e, f)
+			}
+
+			// The rings lie on the grid already, so clipping one by itself
+			// must give it back unchanged
+			for _, ring := range got {
+				again, err := ClipWithOptions(ring, ring, opts)
+				if err != nil {
+					t.Fatalf("backend %d: clip %v again: %v", backend, ring, err)
+				}
+				if len(again) != 1 || !sameVertices(again[0], ring) {
+					t.Fatalf("backend %d: clipping %v again gave %v", backend, ring, again)
+				}
+			}
+		}
+	}
+}
+
+func TestClipWithOptionsGrid(t *testing.T) {
+	const grid = 0.25
+	target := Polygon{{0.1, 0.05}, {9.93, 0.2}, {10.02, 9.87}, {0.04, 10.1}}
+	clip := Polygon{{-1.3, 3.33}, {7.77, -2.1}, {12.4, 6.01}, {3.1, 11.9}}
+	opts := ClipOptions{Grid: grid, Output: OutputRings}
+
+	first, err := ClipWithOptions(target, clip, opts)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("got %d rings, want 1: %v", len(first), first)
+	}
+	ring := first[0]
+	if !isSimpleRing(ring) {
+		t.Fatalf("ring %v is not simple", ring)
+	}
+	for _, v := range ring {
+		for _, x := range v[:2] {
+			if x/grid != math.Round(x/grid) {
+				t.Fatalf("vertex %v is not on the grid", v)
+			}
+		}
+	}
+
+	// clipping the snapped result again must not move anything
+	again, err := ClipWithOptions(ring, clip, opts)
+	if err != nil {
+		t.Fatalf("clip again: %v", err)
+	}
+	if len(again) != 1 || len(again[0]) != len(ring) {
+		t.Fatalf("clipping again gave %v, want %v", again, ring)
+	}
+	for _, v := range again[0] {
+		found := false
+		for _, w := range ring {
+			found = found || v == w
+		}
+		if !found {
+			t.Fatalf("clipping again moved vertex %v", v)
+		}
+	}
+}
+
+func TestClipWithOptionsGridDecimal(t *testing.T) {
+	target := Polygon{{0.04, 0.02}, {0.93, 0.01}, {0.97, 0.88}, {0.02, 0.91}}
+	clip := Polygon{{0.31, -1}, {2, -1}, {2, 0.68}, {0.31, 0.68}}
+
+	got, err := ClipWithOptions(target, clip, ClipOptions{Grid: 0.1, Output: OutputRings})
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	// the vertices are the doubles closest to the grid points, so they
+	// compare equal to the decimal literals
+	want := map[[2]float64]bool{{0.3, 0}: true, {0.9, 0}: true, {1, 0.7}: true, {0.3, 0.7}: true}
+	if len(got) != 1 || len(got[0]) != len(want) {
+		t.Fatalf("got %v, want one ring through %v", got, want)
+	}
+	for _, v := range got[0] {
+		if !want[[2]float64{v[0], v[1]}] {
+			t.Fatalf("vertex %v is not one of %v", v, want)
+		}
+	}
+}
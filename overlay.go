@@ -0,0 +1,556 @@
+package clippoly
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// boolOp selects which faces of the overlay of the target and clip rings end
+// up in the result.
+type boolOp int
+
+const (
+	opIntersection boolOp = iota
+	opUnion
+	opDifference
+	opXor
+)
+
+// contains reports whether a face with the given target and clip coverage
+// belongs to the result of op.
+func (op boolOp) contains(inTarget, inClip bool) bool {
+	switch op {
+	case opUnion:
+		return inTarget || inClip
+	case opDifference:
+		return inTarget && !inClip
+	case opXor:
+		return inTarget != inClip
+	default:
+		return inTarget && inClip
+	}
+}
+
+// overlayEdge is an edge of the noded target/clip graph. count holds the net
+// number of target (0) and clip (1) boundary pieces running from -> to.
+type overlayEdge struct {
+	from, to *node
+	count    [2]int
+	used     bool
+}
+
+// cancelSteps is the number of loop steps between two checks of the context
+// in the quadratic noding and winding loops
+const cancelSteps = 4096
+
+// canceller checks a context every cancelSteps steps of a long loop, where
+// checking it on every step would cost more than the step itself. A nil
+// canceller never stops.
+type canceller struct {
+	ctx   context.Context
+	steps int
+	err   error
+}
+
+func newCanceller(ctx context.Context) *canceller {
+	return &canceller{ctx: ctx}
+}
+
+// tick counts n steps and reports whether the loop should stop because the
+// context is done.
+func (c *canceller) tick(n int) bool {
+	if c == nil {
+		return false
+	}
+	if c.err == nil {
+		if c.steps += n; c.steps >= cancelSteps {
+			c.steps = 0
+			c.err = c.ctx.Err()
+		}
+	}
+	return c.err != nil
+}
+
+// Err returns the context error once tick has reported it.
+func (c *canceller) Err() error {
+	if c == nil {
+		return nil
+	}
+	return c.err
+}
+
+// overlay nodes the target and clip rings against each other, keeps the
+// edges that separate a face of the result from a face outside it and traces
+// them into rings. Outer rings come out counter-clockwise and holes clockwise.
+// It stops with the context error once ctx is done, checking while noding and
+// tracing.
+func (tol tolerance) overlay(ctx context.Context, target, clip Polygons, op boolOp, rule FillRule) (Polygons, error) {
+	idGen := &idGenerator{}
+
+	targetRings, clipRings := target, clip
+	if tol.integer {
+		var err error
+		if targetRings, clipRings, err = tol.snapRound(ctx, target, clip); err != nil {
+			return nil, err
+		}
+	}
+
+	rings := make([][]*node, 0, len(target)+len(clip))
+	owners := make([]int, 0, len(target)+len(clip))
+	for _, ring := range targetRings {
+		rings = append(rings, makeShapeWithID(tol.dedupRing(ring), true, idGen))
+		owners = append(owners, 0)
+	}
+	for _, ring := range clipRings {
+		rings = append(rings, makeShapeWithID(tol.dedupRing(ring), false, idGen))
+		owners = append(owners, 1)
+	}
+
+	n := 0
+	for _, ring := range rings {
+		n += len(ring)
+	}
+
+	var ringEdges [][][]*node
+	var err error
+	if tol.useSweep(n) {
+		ringEdges, err = tol.nodeSweep(ctx, rings, idGen)
+	} else {
+		ringEdges, err = tol.nodePairwise(ctx, rings, idGen)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	graph := buildOverlayEdges(ringEdges, owners)
+	var probes [][2]int
+	if tol.useSweep(n) {
+		probes, err = windingsSweep(ctx, graph)
+	} else {
+		probes, err = windings(graph, newCanceller(ctx))
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := selectOverlayEdges(graph, probes, op, rule)
+
+	out, err := tol.traceRings(ctx, result, traceLimit(n))
+	switch e := err.(type) {
+	case *LoopNotClosedError:
+		e.Target, e.Clip = target, clip
+	case *IterationLimitError:
+		e.Target, e.Clip = target, clip
+	}
+	return out, err
+}
+
+// nodePairwise splits the edges of rings where they touch or cross by
+// comparing every edge with every other edge.
+func (tol tolerance) nodePairwise(ctx context.Context, rings [][]*node, idGen *idGenerator) ([][][]*node, error) {
+	if err := tol.mergePairwise(rings, newCanceller(ctx)); err != nil {
+		return nil, err
+	}
+
+	ringEdges := make([][][]*node, len(rings))
+	for j := range rings {
+		ringEdges[j] = edges(rings[j])
+	}
+	return tol.splitPairwise(ctx, rings, ringEdges, idGen)
+}
+
+// mergePairwise replaces every node of rings by the first node within
+// tolerance of it. It gives up with the context error once stop does.
+func (tol tolerance) mergePairwise(rings [][]*node, stop *canceller) error {
+	// Self-intersecting rings are noded against themselves as well, so that
+	// bow-ties and loops resolve according to the fill rule
+	for _, ring := range rings {
+		for i := range ring {
+			for j := i + 1; j < len(ring); j++ {
+				tol.mergeCoincidentNodes(ring[i:i+1], ring[j:j+1])
+			}
+			if stop.tick(len(ring) - i) {
+				return stop.Err()
+			}
+		}
+	}
+	for i := range rings {
+		for j := i + 1; j < len(rings); j++ {
+			for k := range rings[i] {
+				tol.mergeCoincidentNodes(rings[i][k:k+1], rings[j])
+				if stop.tick(len(rings[j])) {
+					return stop.Err()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// splitPairwise splits ringEdges, the edges between the nodes of rings, at
+// the nodes lying on them and where they cross each other.
+func (tol tolerance) splitPairwise(ctx context.Context, rings [][]*node, ringEdges [][][]*node, idGen *idGenerator) ([][][]*node, error) {
+	stop := newCanceller(ctx)
+	for j := range rings {
+		for i := range rings {
+			for k := range rings[i] {
+				_, ringEdges[j] = tol.intersectPointOnEdge(rings[i][k:k+1], ringEdges[j])
+				if stop.tick(len(ringEdges[j])) {
+					return nil, stop.Err()
+				}
+			}
+		}
+	}
+
+	vertices := idGen.current
+	for i := range rings {
+		ringEdges[i] = tol.intersectSelf(ringEdges[i], idGen, stop)
+		for j := i + 1; j < len(rings); j++ {
+			ringEdges[i], ringEdges[j] = tol.intersect(ringEdges[i], ringEdges[j], idGen, stop)
+		}
+		if err := stop.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Once an edge is split at a crossing, findIntersect no longer sees
+	// other edges pass through it, as they meet the pieces at an end point.
+	// This happens where edges overlap or several edges cross in one point,
+	// so every edge is split at the crossings lying on it here, as nodeSweep
+	// does with its aliases.
+	crossings := crossingNodes(ringEdges, vertices)
+	for j := range ringEdges {
+		for k := range crossings {
+			_, ringEdges[j] = tol.intersectPointOnEdge(crossings[k:k+1], ringEdges[j])
+			if stop.tick(len(ringEdges[j])) {
+				return nil, stop.Err()
+			}
+		}
+	}
+
+	return ringEdges, nil
+}
+
+// crossingNodes returns the nodes of ringEdges numbered after the first n,
+// which are the crossings found by the noding, in the order they were found
+func crossingNodes(ringEdges [][][]*node, n int) []*node {
+	var crossings []*node
+	seen := make(map[*node]bool)
+	for _, pieces := range ringEdges {
+		for _, p := range pieces {
+			for _, c := range p {
+				if c.id > n && !seen[c] {
+					seen[c] = true
+					crossings = append(crossings, c)
+				}
+			}
+		}
+	}
+	sort.Slice(crossings, func(i, j int) bool { return crossings[i].id < crossings[j].id })
+	return crossings
+}
+
+// traceLimit bounds the number of tracing steps for inputs with n vertices in
+// total. Each of the n edges is split at most once by every other edge and
+// every vertex, so the noded graph has fewer than 2n² edges and every step
+// uses one of them.
+func traceLimit(n int) int {
+	return 2 * n * n
+}
+
+// dedupRing drops repeated consecutive vertices, including a closing vertex
+// equal to the first one.
+func (tol tolerance) dedupRing(ring Polygon) Polygon {
+	out := make(Polygon, 0, len(ring))
+	for _, c := range ring {
+		if len(out) > 0 && tol.coordsEqual(out[len(out)-1], c) {
+			continue
+		}
+		out = append(out, c)
+	}
+	for len(out) > 1 && tol.coordsEqual(out[0], out[len(out)-1]) {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// buildOverlayEdges merges the split ring edges into one edge per node pair,
+// keeping track of how often the target and the clip run along it.
+func buildOverlayEdges(ringEdges [][][]*node, owners []int) []*overlayEdge {
+	n := 0
+	for _, pieces := range ringEdges {
+		n += len(pieces)
+	}
+	index := make(map[[2]int]*overlayEdge, n)
+	graph := make([]*overlayEdge, 0, n)
+
+	for r, pieces := range ringEdges {
+		owner := owners[r]
+		for _, p := range pieces {
+			a, b := p[0], p[1]
+			if a == b {
+				continue
+			}
+			key := [2]int{a.id, b.id}
+			if key[0] > key[1] {
+				key[0], key[1] = key[1], key[0]
+			}
+			e, ok := index[key]
+			if !ok {
+				e = &overlayEdge{from: a, to: b}
+				index[key] = e
+				graph = append(graph, e)
+			}
+			if e.from == a {
+				e.count[owner]++
+			} else {
+				e.count[owner]--
+			}
+		}
+	}
+
+	return graph
+}
+
+// windings returns for every edge of graph the target and clip winding at
+// its midpoint, ignoring the edge itself. This is the winding of the face
+// just past the edge in +x direction (+y for horizontal edges). It gives up
+// with the context error once stop does.
+func windings(graph []*overlayEdge, stop *canceller) ([][2]int, error) {
+	probes := make([][2]int, len(graph))
+	for i, e := range graph {
+		if e.count == [2]int{} {
+			continue
+		}
+		if stop.tick(len(graph)) {
+			return nil, stop.Err()
+		}
+		u, v := e.from.coord, e.to.coord
+		mid := Coord{(u[0] + v[0]) / 2, (u[1] + v[1]) / 2}
+		for _, f := range graph {
+			if f == e {
+				continue
+			}
+			c := crossing(mid, f.from.coord, f.to.coord)
+			probes[i][0] += c * f.count[0]
+			probes[i][1] += c * f.count[1]
+		}
+	}
+	return probes, nil
+}
+
+// selectOverlayEdges keeps the edges that have a result face on exactly one
+// side and orients them so that the result lies on their left. probes holds
+// the windings of every edge as returned by windings. Faces count as inside
+// the target or clip according to rule.
+func selectOverlayEdges(graph []*overlayEdge, probes [][2]int, op boolOp, rule FillRule) []*overlayEdge {
+	result := make([]*overlayEdge, 0, len(graph))
+
+	for i, e := range graph {
+		if e.count == [2]int{} {
+			continue
+		}
+
+		u, v := e.from.coord, e.to.coord
+		probe := probes[i]
+		left, right := probe, probe
+		if v[1] < u[1] || (v[1] == u[1] && v[0] > u[0]) {
+			right[0] -= e.count[0]
+			right[1] -= e.count[1]
+		} else {
+			left[0] += e.count[0]
+			left[1] += e.count[1]
+		}
+
+		inLeft := op.contains(rule.contains(left[0]), rule.contains(left[1]))
+		inRight := op.contains(rule.contains(right[0]), rule.contains(right[1]))
+		if inLeft == inRight {
+			continue
+		}
+		if inRight {
+			// Flipping keeps count describing from -> to, so the windings of
+			// the edges that follow still see the same geometry
+			e.from, e.to = e.to, e.from
+			e.count[0], e.count[1] = -e.count[0], -e.count[1]
+		}
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// crossing returns the signed contribution of edge a->b to the winding number
+// of pt, counting crossings of the horizontal ray running right from pt.
+func crossing(pt, a, b Coord) int {
+	if a[1] <= pt[1] {
+		if b[1] > pt[1] && orient(a, b, pt) > 0 {
+			return 1
+		}
+	} else if b[1] <= pt[1] && orient(a, b, pt) < 0 {
+		return -1
+	}
+	return 0
+}
+
+// traceRings walks the directed result edges into closed rings, always taking
+// the first outgoing edge clockwise from the one we arrived on so that rings
+// touching in a single vertex are traced separately.
+func (tol tolerance) traceRings(ctx context.Context, result []*overlayEdge, limit int) (Polygons, error) {
+	outgoing := make(map[*node][]*overlayEdge, len(result))
+	for _, e := range result {
+		outgoing[e.from] = append(outgoing[e.from], e)
+	}
+
+	var rings Polygons
+	steps := 0
+	for _, start := range result {
+		if start.used {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		loop := make([]*node, 0, 8)
+		cur := start
+		for {
+			if steps++; steps > limit {
+				return nil, &IterationLimitError{Limit: limit, Loop: nodeCoords(loop)}
+			}
+			cur.used = true
+			loop = append(loop, cur.from)
+
+			next := nextRingEdge(cur, outgoing[cur.to])
+			if next == start {
+				break
+			}
+			if next == nil || next.used {
+				return nil, &LoopNotClosedError{Loop: nodeCoords(append(loop, cur.to))}
+			}
+			cur = next
+		}
+
+		for _, ring := range splitRepeatedNodes(loop) {
+			ring = tol.removeRedundantNodes(ring)
+			if len(ring) < 3 {
+				continue
+			}
+			poly := make(Polygon, len(ring))
+			for i, n := range ring {
+				poly[i] = n.coord
+			}
+			if tol.negligible(poly) {
+				continue
+			}
+			rings = append(rings, poly)
+		}
+	}
+
+	return rings, nil
+}
+
+// nextRingEdge picks the outgoing edge that comes first when turning
+// clockwise from the direction back along e. Directions are ordered with
+// exact orientations rather than angles: first by whether they lie within
+// half a turn clockwise of back, then by which side of each other they lie.
+func nextRingEdge(e *overlayEdge, candidates []*overlayEdge) *overlayEdge {
+	at, back := e.to.coord, e.from.coord
+
+	// half is 0 for directions up to half a turn clockwise from back, and 1
+	// for the rest, which end with back itself
+	half := func(c Coord) int {
+		switch o := orient(at, back, c); {
+		case o < 0:
+			return 0
+		case o > 0:
+			return 1
+		}
+		if (c[0]-at[0])*(back[0]-at[0])+(c[1]-at[1])*(back[1]-at[1]) < 0 {
+			return 0
+		}
+		return 1
+	}
+
+	var best *overlayEdge
+	bestHalf := 0
+	for _, c := range candidates {
+		h := half(c.to.coord)
+		// Within a half, the smaller turn lies counter-clockwise of the
+		// larger one
+		if best == nil || h < bestHalf || (h == bestHalf && orient(at, best.to.coord, c.to.coord) > 0) {
+			best, bestHalf = c, h
+		}
+	}
+	return best
+}
+
+// splitRepeatedNodes cuts a closed walk that passes a node more than once into
+// simple rings.
+func splitRepeatedNodes(loop []*node) [][]*node {
+	var rings [][]*node
+	stack := make([]*node, 0, len(loop))
+	pos := make(map[*node]int, len(loop))
+
+	for _, n := range loop {
+		if i, ok := pos[n]; ok {
+			rings = append(rings, append([]*node(nil), stack[i:]...))
+			for _, m := range stack[i+1:] {
+				delete(pos, m)
+			}
+			stack = stack[:i+1]
+			continue
+		}
+		pos[n] = len(stack)
+		stack = append(stack, n)
+	}
+
+	return append(rings, stack)
+}
+
+// removeRedundantNodes drops nodes that lie on the straight line between
+// their neighbours, both in plan and in height.
+func (tol tolerance) removeRedundantNodes(ring []*node) []*node {
+	for changed := true; changed && len(ring) >= 3; {
+		changed = false
+		for i := 0; i < len(ring) && len(ring) >= 3; i++ {
+			prev := ring[(i-1+len(ring))%len(ring)].coord
+			next := ring[(i+1)%len(ring)].coord
+			if tol.isRedundant(prev, ring[i].coord, next) {
+				ring = append(ring[:i], ring[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return ring
+}
+
+func (tol tolerance) isRedundant(prev, cur, next Coord) bool {
+	if !tol.pointOnEdge(cur[0], cur[1], prev[0], prev[1], next[0], next[1]) {
+		return false
+	}
+	dx, dy := next[0]-prev[0], next[1]-prev[1]
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return true
+	}
+	t := ((cur[0]-prev[0])*dx + (cur[1]-prev[1])*dy) / l2
+	return math.Abs(prev[2]+t*(next[2]-prev[2])-cur[2]) < tol.eps
+}
+
+// signedArea returns the area enclosed by poly, positive for
+// counter-clockwise rings.
+func signedArea(poly Polygon) float64 {
+	if len(poly) == 0 {
+		return 0
+	}
+	// Measure from the first vertex, so that rings far from the origin do
+	// not lose their area to cancellation
+	o := poly[0]
+	var sum float64
+	for i := range poly {
+		j := (i + 1) % len(poly)
+		sum += (poly[i][0]-o[0])*(poly[j][1]-o[1]) - (poly[j][0]-o[0])*(poly[i][1]-o[1])
+	}
+	return sum / 2
+}
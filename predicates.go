@@ -0,0 +1,82 @@
+package clippoly
+
+import (
+	"math"
+	"math/big"
+)
+
+// Relative error bound of the floating-point filter in orient, following
+// Shewchuk's "Adaptive Precision Floating-Point
+// Arithmetic and Fast Robust Geometric Predicates".
+//
+// Which side of an edge a point lies on and whether two edges cross are
+// decided by these exact signs. Whether a point lies on an edge or on
+// another point (pointOnEdge, snapsTo, coordsEqual and the boundary test in
+// isInsideNodes) stays within the noding tolerance on purpose: those tests
+// merge points that rounding has pulled apart, which exact signs would keep
+// apart as slivers.
+var (
+	machineEpsilon = math.Ldexp(1, -53)
+	orientErrBound = (3 + 16*machineEpsilon) * machineEpsilon
+)
+
+// orient is twice the signed area of the triangle a, b, c. It is positive
+// when c lies left of a->b. The sign is exact: when rounding could have
+// flipped it, the determinant is recomputed in exact arithmetic.
+func orient(a, b, c Coord) float64 {
+	detLeft := (a[0] - c[0]) * (b[1] - c[1])
+	detRight := (a[1] - c[1]) * (b[0] - c[0])
+	det := detLeft - detRight
+
+	var detSum float64
+	switch {
+	case detLeft > 0:
+		if detRight <= 0 {
+			return det
+		}
+		detSum = detLeft + detRight
+	case detLeft < 0:
+		if detRight >= 0 {
+			return det
+		}
+		detSum = -detLeft - detRight
+	default:
+		return det
+	}
+
+	if bound := orientErrBound * detSum; det >= bound || -det >= bound {
+		return det
+	}
+	if math.IsNaN(det) || math.IsInf(detSum, 0) {
+		// There is no exact value to fall back on
+		return det
+	}
+	return orientExact(a, b, c)
+}
+
+func orientExact(a, b, c Coord) float64 {
+	acx, acy := ratSub(a[0], c[0]), ratSub(a[1], c[1])
+	bcx, bcy := ratSub(b[0], c[0]), ratSub(b[1], c[1])
+
+	left := new(big.Rat).Mul(acx, bcy)
+	right := new(big.Rat).Mul(acy, bcx)
+	det, _ := left.Sub(left, right).Float64()
+	return det
+}
+
+// ratSub returns a - b without rounding
+func ratSub(a, b float64) *big.Rat {
+	r := new(big.Rat).SetFloat64(a)
+	return r.Sub(r, new(big.Rat).SetFloat64(b))
+}
+
+// properCrossing checks if the segments a1-a2 and b1-b2 cross at a single
+// point that is not an end point of either, using exact orientation signs.
+func properCrossing(a1, a2, b1, b2 Coord) bool {
+	return oppositeSides(orient(a1, a2, b1), orient(a1, a2, b2)) &&
+		oppositeSides(orient(b1, b2, a1), orient(b1, b2, a2))
+}
+
+func oppositeSides(o1, o2 float64) bool {
+	return (o1 > 0 && o2 < 0) || (o1 < 0 && o2 > 0)
+}
@@ -0,0 +1,91 @@
+package clippoly
+
+import (
+	"math"
+	"testing"
+)
+
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+func TestOrientNearlyCollinear(t *testing.T) {
+	// Points within a few ulps of the line y = x, where the plain
+	// determinant is dominated by rounding error
+	b, c := Coord{12, 12}, Coord{24, 24}
+	naiveWrong := 0
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 64; j++ {
+			a := Coord{0.5 + float64(i)*math.Ldexp(1, -53), 0.5 + float64(j)*math.Ldexp(1, -53)}
+			want := sign(orientExact(a, b, c))
+			if got := sign(orient(a, b, c)); got != want {
+				t.Fatalf("orient(%v, %v, %v) sign = %d, want %d", a, b, c, got, want)
+			}
+			naive := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+			if sign(naive) != want {
+				naiveWrong++
+			}
+		}
+	}
+	if naiveWrong == 0 {
+		t.Fatalf("expected plain float arithmetic to get some of these signs wrong")
+	}
+}
+
+func TestOrientNonFinite(t *testing.T) {
+	a, b := Coord{2, 0}, Coord{0, 0}
+	for _, c := range []Coord{{math.NaN(), 2}, {math.Inf(1), 2}, {0, math.Inf(-1)}} {
+		// must not fall back on exact arithmetic, which has no infinities
+		if got := orient(a, b, c); !math.IsNaN(got) && !math.IsInf(got, 0) {
+			t.Fatalf("orient(%v, %v, %v) = %g, want a non-finite value", a, b, c, got)
+		}
+	}
+}
+
+func TestSegmentsIntersectNearlyParallel(t *testing.T) {
+	// b runs almost along a and crosses it once near the middle; the answer
+	// must not depend on the order of the segments
+	a1, a2 := Coord{0, 0}, Coord{1e9, 1}
+	b1, b2 := Coord{0, -1e-9}, Coord{1e9, 1 + 1e-7}
+
+	if !segmentsIntersect(a1, a2, b1, b2) || !segmentsIntersect(b2, b1, a2, a1) {
+		t.Fatalf("expected the nearly parallel segments to cross")
+	}
+}
+
+func TestNextRingEdge(t *testing.T) {
+	// arriving at the origin from the west, the first edge clockwise from
+	// west is the one turning furthest left
+	at := &node{coord: Coord{0, 0}}
+	in := &overlayEdge{from: &node{coord: Coord{-1, 0}}, to: at}
+	out := func(x, y float64) *overlayEdge { return &overlayEdge{from: at, to: &node{coord: Coord{x, y}}} }
+
+	north, nearlyNorth := out(0, 1), out(-1e-17, 1)
+	east, south, west := out(1, 0), out(0, -1), out(-2, 0)
+
+	tests := []struct {
+		name       string
+		candidates []*overlayEdge
+		want       *overlayEdge
+	}{
+		// Atan2 rounds both to the same angle and kept the first
+		{name: "nearly_north", candidates: []*overlayEdge{north, nearlyNorth, east}, want: nearlyNorth},
+		{name: "straight_on", candidates: []*overlayEdge{south, east}, want: east},
+		{name: "right_turn", candidates: []*overlayEdge{west, south}, want: south},
+		{name: "back", candidates: []*overlayEdge{west}, want: west},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRingEdge(in, tt.candidates); got != tt.want {
+				t.Fatalf("got edge to %v, want edge to %v", got.to.coord, tt.want.to.coord)
+			}
+		})
+	}
+}
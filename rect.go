@@ -0,0 +1,196 @@
+package clippoly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ClipRect returns the part of poly inside the axis-aligned rectangle from
+// (minX, minY) to (maxX, maxY). It skips the overlay that Clip runs and cuts
+// poly against the four sides directly, so it suits tiling many geometries.
+// Concave polygons, such as ones that wrap around the rectangle, may fall
+// apart into several pieces. Rings are oriented as in Union, and new vertices
+// take their height from the polygon edge they lie on.
+func ClipRect(poly Polygon, minX, minY, maxX, maxY float64) (Polygons, error) {
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, err
+	}
+	r := bounds{minX: minX, minY: minY, maxX: maxX, maxY: maxY}
+	if err := validateRect(r); err != nil {
+		return nil, err
+	}
+
+	tol := scaledTolerance(Polygons{poly, r.ring()})
+	return newRectClipper(r, nil, tol).clipRings(poly)
+}
+
+// ClipMeshRect clips all faces of a mesh against the axis-aligned rectangle
+// from (minX, minY) to (maxX, maxY), like ClipMesh. Vertices at the corners
+// of the rectangle take their height from the face they lie in.
+func ClipMeshRect(vertices []Coord, faces [][3]int, minX, minY, maxX, maxY float64) ([]Coord, [][3]int, error) {
+	r := bounds{minX: minX, minY: minY, maxX: maxX, maxY: maxY}
+	if err := validateRect(r); err != nil {
+		return nil, nil, err
+	}
+	return clipMeshFaces(context.Background(), vertices, faces, Region{Exterior: r.ring()}, nil)
+}
+
+func validateRect(r bounds) error {
+	if !(r.minX < r.maxX && r.minY < r.maxY) || math.IsInf(r.minX, 0) || math.IsInf(r.minY, 0) ||
+		math.IsInf(r.maxX, 0) || math.IsInf(r.maxY, 0) {
+		return fmt.Errorf("rectangle (%g, %g)-(%g, %g) must have a finite, positive width and height", r.minX, r.minY, r.maxX, r.maxY)
+	}
+	return nil
+}
+
+// ring returns the corners of b counter-clockwise
+func (b bounds) ring() Polygon {
+	return Polygon{{b.minX, b.minY}, {b.maxX, b.minY}, {b.maxX, b.maxY}, {b.minX, b.maxY}}
+}
+
+// rectBounds returns the bounds of poly if it is an axis-aligned rectangle
+func rectBounds(poly Polygon) (bounds, bool) {
+	b := polygonBounds(poly)
+	if len(poly) != 4 || !(b.minX < b.maxX && b.minY < b.maxY) || !isConvex(poly) {
+		return b, false
+	}
+	for _, v := range poly {
+		if (v[0] != b.minX && v[0] != b.maxX) || (v[1] != b.minY && v[1] != b.maxY) {
+			return b, false
+		}
+	}
+	return b, true
+}
+
+// rectClipper clips polygons against an axis-aligned rectangle with the
+// Sutherland–Hodgman algorithm. It does the work of convexClipper with plain
+// coordinate comparisons, and also reuses its buffers between calls.
+type rectClipper struct {
+	rect bounds
+	// corners, if set, is the clip ring whose corners the result takes over
+	// as they are, heights included
+	corners Polygon
+	tol     tolerance
+	in, out Polygon
+}
+
+func newRectClipper(r bounds, corners Polygon, tol tolerance) *rectClipper {
+	return &rectClipper{rect: r, corners: corners, tol: tol}
+}
+
+// inset returns how far p lies inside the given side of the rectangle,
+// counting the sides left, right, bottom and top
+func (c *rectClipper) inset(side int, p Coord) float64 {
+	switch side {
+	case 0:
+		return p[0] - c.rect.minX
+	case 1:
+		return c.rect.maxX - p[0]
+	case 2:
+		return p[1] - c.rect.minY
+	default:
+		return c.rect.maxY - p[1]
+	}
+}
+
+// clip returns subject cut back to the rectangle, in the winding of subject
+// and with the same conventions as convexClipper.clip. Where a concave
+// subject leaves the rectangle more than once, the pieces stay connected by
+// edges running back and forth along its sides; clipRings separates them.
+func (c *rectClipper) clip(subject Polygon) Polygon {
+	c.in = append(c.in[:0], subject...)
+
+	for side := 0; side < 4; side++ {
+		c.out = c.out[:0]
+		for j := range c.in {
+			p, q := c.in[j], c.in[(j+1)%len(c.in)]
+			dp, dq := c.inset(side, p), c.inset(side, q)
+			if dp >= -c.tol.eps {
+				c.out = append(c.out, p)
+			}
+			if (dp > c.tol.eps && dq < -c.tol.eps) || (dp < -c.tol.eps && dq > c.tol.eps) {
+				// The cut lies exactly on the side
+				v := cutEdge(p, q, dp, dq)
+				switch side {
+				case 0:
+					v[0] = c.rect.minX
+				case 1:
+					v[0] = c.rect.maxX
+				case 2:
+					v[1] = c.rect.minY
+				default:
+					v[1] = c.rect.maxY
+				}
+				c.out = append(c.out, v)
+			}
+		}
+		c.in, c.out = c.out, c.in
+		if len(c.in) == 0 {
+			return nil
+		}
+	}
+
+	ring := c.tol.compact(c.in)
+	for i, v := range ring {
+		if !vertexOf(subject, v) {
+			for _, corner := range c.corners {
+				if c.tol.coordsEqual(v, corner) {
+					ring[i] = corner
+				}
+			}
+		}
+	}
+	if len(ring) < 3 || c.tol.negligible(ring) {
+		return nil
+	}
+	return ring
+}
+
+// clipRings clips poly and returns the pieces as in Union
+func (c *rectClipper) clipRings(poly Polygon) (Polygons, error) {
+	ring := c.clip(poly)
+	if ring == nil {
+		return nil, nil
+	}
+	ring = append(Polygon(nil), ring...)
+	if isConvex(poly) || !c.doublesBack(ring) {
+		return Polygons{orientRing(ring, true)}, nil
+	}
+
+	// The back and forth edges cancel in the overlay, which leaves the
+	// separate pieces
+	return scaledTolerance(Polygons{ring}).overlay(context.Background(), Polygons{ring}, nil, opUnion, NonZero)
+}
+
+// doublesBack checks if ring runs along a side of the rectangle more than
+// once over the same stretch
+func (c *rectClipper) doublesBack(ring Polygon) bool {
+	var spans [4][][2]float64
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		for side := 0; side < 4; side++ {
+			if math.Abs(c.inset(side, p)) > c.tol.eps || math.Abs(c.inset(side, q)) > c.tol.eps {
+				continue
+			}
+			axis := 0
+			if side < 2 {
+				axis = 1
+			}
+			spans[side] = append(spans[side], [2]float64{math.Min(p[axis], q[axis]), math.Max(p[axis], q[axis])})
+		}
+	}
+
+	for _, s := range spans {
+		sort.Slice(s, func(i, j int) bool { return s[i][0] < s[j][0] })
+		reach := math.Inf(-1)
+		for _, span := range s {
+			if span[0] < reach-c.tol.eps {
+				return true
+			}
+			reach = math.Max(reach, span[1])
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package clippoly
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClipRect(t *testing.T) {
+	rect := bounds{minX: 0, minY: 0, maxX: 4, maxY: 4}
+
+	tests := []struct {
+		name   string
+		poly   Polygon
+		pieces int
+	}{
+		{name: "overlap", poly: square(2, 2, 4), pieces: 1},
+		{name: "inside", poly: square(1, 1, 2), pieces: 1},
+		{name: "covers", poly: square(-1, -1, 6), pieces: 1},
+		{name: "disjoint", poly: square(5, 5, 1), pieces: 0},
+		{name: "clockwise", poly: orientRing(square(2, 2, 4), false), pieces: 1},
+		// comb whose teeth reach down into the rectangle from above
+		{name: "comb", poly: Polygon{{-1, 2}, {1, 2}, {1, 5}, {2, 5}, {2, 2}, {3, 2}, {3, 5}, {5, 5}, {5, 6}, {-1, 6}}, pieces: 2},
+		// C wrapping around the rectangle with its opening to the right
+		{name: "wrapping", poly: Polygon{{-1, -1}, {6, -1}, {6, 1}, {2, 1}, {2, 3}, {6, 3}, {6, 5}, {-1, 5}}, pieces: 1},
+		// spiral arm leaving and re-entering the rectangle through one side
+		{name: "re_entering", poly: Polygon{{1, 1}, {6, 1}, {6, 3}, {3, 3}, {3, 2}, {5, 2}, {5, 2.5}, {5.5, 2.5}, {5.5, 1.5}, {1.5, 1.5}, {1.5, 3.5}, {1, 3.5}}, pieces: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClipRect(tt.poly, rect.minX, rect.minY, rect.maxX, rect.maxY)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			want, err := Boolean{}.overlay(Polygons{tt.poly}, Polygons{rect.ring()}, opIntersection, EvenOdd)
+			if err != nil {
+				t.Fatalf("overlay: %v", err)
+			}
+			if len(got) != tt.pieces || len(want) != tt.pieces {
+				t.Fatalf("got %d pieces, overlay %d, want %d: %v", len(got), len(want), tt.pieces, got)
+			}
+			if math.Abs(totalArea(got)-totalArea(want)) > 1e-9 {
+				t.Fatalf("area = %.3f, want %.3f", totalArea(got), totalArea(want))
+			}
+			for _, r := range got {
+				if signedArea(r) <= 0 || !isSimpleRing(r) {
+					t.Fatalf("ring %v is not a simple counter-clockwise ring", r)
+				}
+			}
+		})
+	}
+}
+
+func TestClipRectInterpolatesZ(t *testing.T) {
+	ramp := Polygon{{-2, -2, -4}, {6, -2, 12}, {6, 6, 12}, {-2, 6, -4}}
+	got, err := ClipRect(orientRing(ramp, false), 0, 0, 4, 4)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	if len(got) != 1 || math.Abs(totalArea(got)-16) > 1e-9 {
+		t.Fatalf("got %v, want the whole rectangle", got)
+	}
+	for _, v := range got[0] {
+		if math.Abs(v[2]-2*v[0]) > 1e-9 {
+			t.Fatalf("vertex %v is off the ramp", v)
+		}
+	}
+}
+
+func TestClipMeshRect(t *testing.T) {
+	vertices := []Coord{{0, 0, 0}, {4, 0, 4}, {4, 4, 4}, {0, 4, 0}}
+	faces := [][3]int{{0, 1, 2}, {0, 2, 3}}
+
+	gotVerts, gotFaces, err := ClipMeshRect(vertices, faces, 1, -1, 3, 2.5)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	var area float64
+	for _, f := range gotFaces {
+		tri := Polygon{gotVerts[f[0]], gotVerts[f[1]], gotVerts[f[2]]}
+		area += signedArea(tri)
+		for _, v := range tri {
+			if v[0] < 1 || v[0] > 3 || v[1] < 0 || v[1] > 2.5 || v[2] != v[0] {
+				t.Fatalf("vertex %v is outside the rectangle or off the mesh", v)
+			}
+		}
+	}
+	if math.Abs(area-5) > 1e-9 {
+		t.Fatalf("area = %.3f, want 5", area)
+	}
+}
+
+func TestRectClipperDoesNotAllocate(t *testing.T) {
+	clipper := newRectClipper(bounds{minX: 0, minY: 0, maxX: 2, maxY: 2}, nil, defaultTolerance)
+	tri := Polygon{{-1, -1}, {3, 0.5}, {0.5, 3}}
+	clipper.clip(tri)
+
+	if allocs := testing.AllocsPerRun(100, func() { clipper.clip(tri) }); allocs != 0 {
+		t.Fatalf("clip allocates %.0f times per call", allocs)
+	}
+}
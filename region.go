@@ -0,0 +1,158 @@
+package clippoly
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Region is an area bounded by one exterior ring with any number of interior
+// rings (holes) cut out of it. Regions returned by this package have a
+// counter-clockwise exterior and clockwise holes; input regions may use any
+// winding.
+type Region struct {
+	Exterior Polygon
+	Holes    Polygons
+}
+
+// rings returns the exterior followed by the holes
+func (r Region) rings() Polygons {
+	rings := make(Polygons, 0, 1+len(r.Holes))
+	rings = append(rings, r.Exterior)
+	return append(rings, r.Holes...)
+}
+
+func (r Region) validate(name string) error {
+	if err := validatePolygon(name+" exterior", r.Exterior); err != nil {
+		return err
+	}
+	for i, h := range r.Holes {
+		if err := validatePolygon(fmt.Sprintf("%s hole %d", name, i), h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Regions groups oriented rings, as returned by Union, Difference and Xor,
+// into regions. Every clockwise ring becomes a hole of the smallest
+// counter-clockwise ring that contains it. A clockwise ring that no
+// counter-clockwise ring contains is reversed into a region of its own,
+// rather than dropped.
+func Regions(rings Polygons) []Region {
+	return scaledTolerance(rings).regions(rings)
+}
+
+// regions is Regions with vertices within tol of a ring counting as on it
+func (tol tolerance) regions(rings Polygons) []Region {
+	var regions []Region
+	var holes Polygons
+	for _, r := range rings {
+		if signedArea(r) >= 0 {
+			regions = append(regions, Region{Exterior: r})
+		} else {
+			holes = append(holes, r)
+		}
+	}
+
+	// Orphaned holes are added once every hole has found its exterior, so
+	// that they take none of the other holes
+	var orphans []Region
+	for _, h := range holes {
+		best := -1
+		bestArea := math.Inf(1)
+		for i, r := range regions {
+			a := signedArea(r.Exterior)
+			if a < bestArea && tol.ringInsideRing(h, r.Exterior) {
+				best, bestArea = i, a
+			}
+		}
+		if best < 0 {
+			orphans = append(orphans, Region{Exterior: orientRing(h, true)})
+			continue
+		}
+		regions[best].Holes = append(regions[best].Holes, h)
+	}
+
+	return append(regions, orphans...)
+}
+
+// ringInsideRing checks if inner lies inside outer, using the first vertex of
+// inner that is not on the boundary of outer
+func (tol tolerance) ringInsideRing(inner, outer Polygon) bool {
+	if !boundingBoxesOverlap(inner, outer) {
+		return false
+	}
+	for _, p := range inner {
+		if tol.verticesOnBoundary(Polygon{p}, outer) {
+			continue
+		}
+		return isInsidePolygon(p, outer, EvenOdd)
+	}
+	return true
+}
+
+// ClipRegion returns the intersection of target and clip as regions. Holes in
+// either input are respected and the result may itself contain holes.
+func ClipRegion(target, clip Region) ([]Region, error) {
+	if err := target.validate("target"); err != nil {
+		return nil, err
+	}
+	if err := clip.validate("clip"); err != nil {
+		return nil, err
+	}
+
+	return scaledTolerance(target.rings(), clip.rings()).intersectRegions(context.Background(), target, clip, EvenOdd)
+}
+
+// intersectRegions is the shared path behind Clip, ClipRegion and ClipMesh.
+func (tol tolerance) intersectRegions(ctx context.Context, target, clip Region, rule FillRule) ([]Region, error) {
+	if !boundingBoxesOverlap(target.Exterior, clip.Exterior) {
+		return nil, nil
+	}
+
+	// Early exit: without holes, self-intersections, crossing or touching
+	// edges one polygon contains the other or they are disjoint. The checks
+	// compare every edge pair, so the sweep goes straight to the overlay.
+	stop := newCanceller(ctx)
+	if rule == EvenOdd && len(target.Holes) == 0 && len(clip.Holes) == 0 &&
+		!tol.useSweep(len(target.Exterior)+len(clip.Exterior)) &&
+		ringIsSimple(target.Exterior, stop) && ringIsSimple(clip.Exterior, stop) &&
+		!polygonsIntersect(target.Exterior, clip.Exterior, stop) && !tol.polygonsTouch(target.Exterior, clip.Exterior, stop) {
+		if err := stop.Err(); err != nil {
+			return nil, err
+		}
+		if isInsidePolygon(target.Exterior[0], clip.Exterior, rule) {
+			return []Region{orientRegion(target)}, nil
+		}
+		if isInsidePolygon(clip.Exterior[0], target.Exterior, rule) {
+			return []Region{orientRegion(clip)}, nil
+		}
+		return nil, nil
+	}
+
+	rings, err := tol.overlay(ctx, target.rings(), clip.rings(), opIntersection, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return tol.regions(rings), nil
+}
+
+// orientRegion returns r with a counter-clockwise exterior and clockwise holes
+func orientRegion(r Region) Region {
+	out := Region{Exterior: orientRing(r.Exterior, true)}
+	for _, h := range r.Holes {
+		out.Holes = append(out.Holes, orientRing(h, false))
+	}
+	return out
+}
+
+// TriangulateRegion triangulates r, leaving its holes uncovered. The
+// triangles run counter-clockwise. Holes may touch the exterior and each
+// other. It fails when a hole does not lie inside the exterior.
+func TriangulateRegion(r Region) (Polygons, error) {
+	r = orientRegion(r)
+	rings := append(Polygons{r.Exterior}, r.Holes...)
+	return scaledTolerance(rings).triangulateRings(rings)
+}
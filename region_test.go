@@ -0,0 +1,241 @@
+package clippoly
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+var courtyard = Region{
+	Exterior: Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+	Holes:    Polygons{{{3, 3}, {7, 3}, {7, 7}, {3, 7}}},
+}
+
+func regionArea(r Region) float64 {
+	a := math.Abs(signedArea(r.Exterior))
+	for _, h := range r.Holes {
+		a -= math.Abs(signedArea(h))
+	}
+	return a
+}
+
+func TestClipRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  Region
+		clip    Region
+		regions int
+		holes   int
+		area    float64
+	}{
+		{
+			name:    "strip_through_courtyard",
+			target:  courtyard,
+			clip:    Region{Exterior: Polygon{{-1, 4}, {11, 4}, {11, 6}, {-1, 6}}},
+			regions: 2,
+			area:    12,
+		},
+		{
+			name:    "clip_around_courtyard",
+			target:  courtyard,
+			clip:    Region{Exterior: Polygon{{1, 1}, {9, 1}, {9, 9}, {1, 9}}},
+			regions: 1,
+			holes:   1,
+			area:    48,
+		},
+		{
+			name:    "hole_in_clip",
+			target:  Region{Exterior: Polygon{{2, 2}, {12, 2}, {12, 8}, {2, 8}}},
+			clip:    courtyard,
+			regions: 1,
+			holes:   1,
+			area:    48 - 16,
+		},
+		{
+			name:    "target_inside_courtyard",
+			target:  Region{Exterior: Polygon{{4, 4}, {6, 4}, {5, 6}}},
+			clip:    courtyard,
+			regions: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClipRegion(tt.target, tt.clip)
+			if err != nil {
+				t.Fatalf("clip region: %v", err)
+			}
+			if len(got) != tt.regions {
+				t.Fatalf("got %d regions, want %d: %v", len(got), tt.regions, got)
+			}
+			var area float64
+			holes := 0
+			for _, r := range got {
+				if signedArea(r.Exterior) <= 0 {
+					t.Fatalf("exterior %v is not counter-clockwise", r.Exterior)
+				}
+				for _, h := range r.Holes {
+					if signedArea(h) >= 0 {
+						t.Fatalf("hole %v is not clockwise", h)
+					}
+				}
+				holes += len(r.Holes)
+				area += regionArea(r)
+			}
+			if holes != tt.holes {
+				t.Fatalf("got %d holes, want %d", holes, tt.holes)
+			}
+			if math.Abs(area-tt.area) > 1e-9 {
+				t.Fatalf("area = %.3f, want %.3f", area, tt.area)
+			}
+		})
+	}
+}
+
+func TestTriangulateRegionLeavesHolesOpen(t *testing.T) {
+	tris, err := TriangulateRegion(Region{
+		Exterior: courtyard.Exterior,
+		Holes: Polygons{
+			{{2, 2}, {2, 4}, {4, 4}, {4, 2}},
+			{{6, 6}, {6, 8}, {8, 8}, {8, 6}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("triangulate: %v", err)
+	}
+
+	var area float64
+	for _, tri := range tris {
+		a := signedArea(tri)
+		if a <= 0 {
+			t.Fatalf("triangle %v is not counter-clockwise", tri)
+		}
+		area += a
+		cx := (tri[0][0] + tri[1][0] + tri[2][0]) / 3
+		cy := (tri[0][1] + tri[1][1] + tri[2][1]) / 3
+		if (cx > 2 && cx < 4 && cy > 2 && cy < 4) || (cx > 6 && cx < 8 && cy > 6 && cy < 8) {
+			t.Fatalf("triangle %v lies inside a hole", tri)
+		}
+	}
+	if math.Abs(area-92) > 1e-9 {
+		t.Fatalf("triangulated area = %.3f, want 92", area)
+	}
+
+	filename := filepath.Join("test_output", "triangulate_region.png")
+	if err := saveTriangleCropPNG(filename, courtyard.Exterior, courtyard.Exterior, tris); err != nil {
+		t.Fatalf("save png: %v", err)
+	}
+}
+
+func TestTriangulateRegionHoleTouchingExterior(t *testing.T) {
+	// The rightmost vertex of the hole lies on an edge of the exterior, not
+	// on one of its vertices
+	hole := Polygon{{5, 4}, {10, 5}, {5, 6}}
+	tris, err := TriangulateRegion(Region{Exterior: square(0, 0, 10), Holes: Polygons{hole}})
+	if err != nil {
+		t.Fatalf("triangulate: %v", err)
+	}
+
+	var area float64
+	for _, tri := range tris {
+		area += signedArea(tri)
+		c := Coord{(tri[0][0] + tri[1][0] + tri[2][0]) / 3, (tri[0][1] + tri[1][1] + tri[2][1]) / 3}
+		if isInsidePolygon(c, hole, EvenOdd) {
+			t.Fatalf("triangle %v lies inside the hole", tri)
+		}
+	}
+	if math.Abs(area-95) > 1e-9 {
+		t.Fatalf("triangulated area = %.3f, want 95", area)
+	}
+}
+
+func TestTriangulateRegionHoleOutside(t *testing.T) {
+	_, err := TriangulateRegion(Region{Exterior: square(0, 0, 10), Holes: Polygons{square(20, 20, 1)}})
+	if err == nil {
+		t.Fatalf("expected an error for a hole outside the exterior")
+	}
+}
+
+func TestClipMeshRegion(t *testing.T) {
+	vertices := []Coord{{-1, -1, 0}, {11, -1, 0}, {11, 11, 0}, {-1, 11, 0}}
+	faces := [][3]int{{0, 1, 2}, {0, 2, 3}}
+
+	newVerts, newFaces, err := ClipMeshRegion(vertices, faces, courtyard)
+	if err != nil {
+		t.Fatalf("clip mesh: %v", err)
+	}
+
+	var area float64
+	for _, f := range newFaces {
+		area += signedArea(Polygon{newVerts[f[0]], newVerts[f[1]], newVerts[f[2]]})
+	}
+	if math.Abs(area-84) > 1e-9 {
+		t.Fatalf("clipped mesh area = %.3f, want 84", area)
+	}
+
+	filename := filepath.Join("test_output", "mesh_clip_region.png")
+	if err := saveMeshClipPNG(filename, vertices, faces, courtyard.Exterior, newVerts, newFaces); err != nil {
+		t.Fatalf("save mesh png: %v", err)
+	}
+}
+
+func TestRegionsSmallScale(t *testing.T) {
+	// a courtyard with an island in it, which has a pond of its own, drawn
+	// at a scale where all rings lie within 1e-9 of each other
+	const s = 1e-10
+	rings := Polygons{
+		orientRing(square(0, 0, 10*s), true),
+		orientRing(square(2*s, 2*s, 6*s), false),
+		orientRing(square(3*s, 3*s, 4*s), true),
+		orientRing(square(4*s, 4*s, 2*s), false),
+	}
+
+	// each exterior keeps the hole drawn right inside it
+	holeArea := map[float64]float64{100: 36, 16: 4}
+	regions := Regions(rings)
+	if len(regions) != 2 {
+		t.Fatalf("got %d regions, want 2", len(regions))
+	}
+	for _, r := range regions {
+		if len(r.Holes) != 1 {
+			t.Fatalf("region %v has %d holes, want 1", r.Exterior, len(r.Holes))
+		}
+		ext := math.Round(signedArea(r.Exterior) / (s * s))
+		if got := math.Round(-signedArea(r.Holes[0]) / (s * s)); got != holeArea[ext] {
+			t.Fatalf("exterior of area %g got a hole of area %g, want %g", ext, got, holeArea[ext])
+		}
+	}
+
+	tris, err := ClipFill(rings, Polygons{square(-s, -s, 12*s)}, EvenOdd)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	if got, want := totalArea(tris), (100-36+16-4)*s*s; math.Abs(got-want) > 1e-9*want {
+		t.Fatalf("area = %g, want %g", got, want)
+	}
+}
+
+func TestRegionsOrphanHole(t *testing.T) {
+	// the clockwise ring lies outside the only exterior, so it cannot be a
+	// hole and comes back as a region of its own
+	rings := Polygons{
+		orientRing(square(0, 0, 4), true),
+		orientRing(square(10, 0, 2), false),
+	}
+
+	regions := Regions(rings)
+	if len(regions) != 2 {
+		t.Fatalf("got %d regions, want 2: %v", len(regions), regions)
+	}
+	for _, r := range regions {
+		if len(r.Holes) != 0 {
+			t.Fatalf("region %v has holes %v, want none", r.Exterior, r.Holes)
+		}
+		if signedArea(r.Exterior) <= 0 {
+			t.Fatalf("exterior %v is not counter-clockwise", r.Exterior)
+		}
+	}
+	if got := signedArea(regions[1].Exterior); got != 4 {
+		t.Fatalf("orphan region has area %g, want 4", got)
+	}
+}
@@ -0,0 +1,18 @@
+package clippoly
+
+// SimplifyPolygon splits a self-intersecting ring, such as a bow-tie or a
+// ring that loops over itself, into simple rings covering the area that is
+// filled under rule. Rings are oriented as in Union, so areas enclosed by the
+// input but not filled come back as clockwise holes.
+func SimplifyPolygon(poly Polygon, rule FillRule) (Polygons, error) {
+	return Boolean{}.SimplifyPolygon(poly, rule)
+}
+
+// SimplifyPolygon is SimplifyPolygon with the backend of o.
+func (o Boolean) SimplifyPolygon(poly Polygon, rule FillRule) (Polygons, error) {
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, err
+	}
+
+	return o.overlay(Polygons{poly}, nil, opUnion, rule)
+}
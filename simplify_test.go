@@ -0,0 +1,134 @@
+package clippoly
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// isSimpleRing checks that no two edges of r cross or overlap
+func isSimpleRing(r Polygon) bool {
+	if len(r) < 3 {
+		return false
+	}
+	for i := range r {
+		for j := i + 1; j < len(r); j++ {
+			if segmentsIntersect(r[i], r[(i+1)%len(r)], r[j], r[(j+1)%len(r)]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestSimplifyPolygon(t *testing.T) {
+	bowTie := Polygon{{0, 0}, {2, 2}, {2, 0}, {0, 2}}
+	// winds twice around [1,3]x[1,3], with a notch left open at x=0
+	looped := Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 1}, {3, 1}, {3, 3}, {1, 3}, {1, 0.5}, {0, 0.5}}
+
+	tests := []struct {
+		name  string
+		poly  Polygon
+		rule  FillRule
+		rings int
+		holes int
+		area  float64
+	}{
+		{name: "bow_tie_even_odd", poly: bowTie, rule: EvenOdd, rings: 2, area: 2},
+		{name: "bow_tie_positive", poly: bowTie, rule: Positive, rings: 1, area: 1},
+		{name: "bow_tie_negative", poly: bowTie, rule: Negative, rings: 1, area: 1},
+		{name: "looped_even_odd", poly: looped, rule: EvenOdd, rings: 2, holes: 1, area: 15.5 - 4},
+		{name: "looped_non_zero", poly: looped, rule: NonZero, rings: 1, area: 15.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SimplifyPolygon(tt.poly, tt.rule)
+			if err != nil {
+				t.Fatalf("simplify: %v", err)
+			}
+			if len(got) != tt.rings {
+				t.Fatalf("got %d rings, want %d: %v", len(got), tt.rings, got)
+			}
+			if holes := countHoles(got); holes != tt.holes {
+				t.Fatalf("got %d holes, want %d", holes, tt.holes)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("area = %.3f, want %.3f", totalArea(got), tt.area)
+			}
+			for _, r := range got {
+				if !isSimpleRing(r) {
+					t.Fatalf("ring %v is not simple", r)
+				}
+			}
+
+			filename := filepath.Join("test_output", fmt.Sprintf("simplify_%s.png", tt.name))
+			if err := saveTriangleCropPNG(filename, tt.poly, tt.poly, got); err != nil {
+				t.Fatalf("save png: %v", err)
+			}
+		})
+	}
+}
+
+func TestClipFillBowTie(t *testing.T) {
+	bowTie := Polygons{{{0, 0}, {2, 2}, {2, 0}, {0, 2}}}
+	clip := Polygons{{{0.5, -1}, {3, -1}, {3, 3}, {0.5, 3}}}
+
+	got, err := ClipFill(bowTie, clip, NonZero)
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	// the right lobe plus the part of the left lobe right of x=0.5
+	if diff := math.Abs(totalArea(got) - 1.25); diff > 1e-9 {
+		t.Fatalf("area = %.4f, want 1.25", totalArea(got))
+	}
+}
+
+func TestClipBowTie(t *testing.T) {
+	bowTie := Polygon{{0, 0}, {2, 2}, {2, 0}, {0, 2}}
+	around := Polygon{{-10, -10}, {10, -10}, {10, 10}, {-10, 10}}
+	strip := Polygon{{0.5, -1}, {3, -1}, {3, 3}, {0.5, 3}}
+
+	tests := []struct {
+		name  string
+		clip  Polygon
+		opts  ClipOptions
+		area  float64
+		rings int
+	}{
+		// the bow-tie lies wholly inside the clip, so nothing crosses
+		{name: "inside_clip", clip: around, area: 2, rings: 2},
+		{name: "inside_clip_simplify", clip: around, opts: ClipOptions{Simplify: true}, area: 2, rings: 2},
+		// only the counter-clockwise left lobe is filled, and the strip
+		// keeps the part of it right of x=0.5
+		{name: "strip_positive_simplify", clip: strip, opts: ClipOptions{Simplify: true, FillRule: Positive}, area: 0.25, rings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClipWithOptions(bowTie, tt.clip, tt.opts)
+			if err != nil {
+				t.Fatalf("clip: %v", err)
+			}
+			if diff := math.Abs(totalArea(got) - tt.area); diff > 1e-9 {
+				t.Fatalf("area = %.4f, want %.4f", totalArea(got), tt.area)
+			}
+
+			opts := tt.opts
+			opts.Output = OutputRings
+			rings, err := ClipWithOptions(bowTie, tt.clip, opts)
+			if err != nil {
+				t.Fatalf("clip outline: %v", err)
+			}
+			if len(rings) != tt.rings {
+				t.Fatalf("got %d rings, want %d: %v", len(rings), tt.rings, rings)
+			}
+			for _, r := range rings {
+				if !isSimpleRing(r) {
+					t.Fatalf("ring %v is not simple", r)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package clippoly
+
+import (
+	"context"
+	"math"
+)
+
+// ClipHalfPlane returns the part of poly left of the directed line through a
+// and b. A concave polygon may fall apart into several pieces. Rings are
+// oriented as in Union, and vertices on the line take their height from the
+// polygon edge they cut.
+func ClipHalfPlane(poly Polygon, a, b Coord) (Polygons, error) {
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, err
+	}
+	if err := validateLine(a, b); err != nil {
+		return nil, err
+	}
+
+	left, _ := halfPlanes(poly, a, b)
+	return clipToHalfPlane(poly, left)
+}
+
+// ClipMeshHalfPlane clips all faces of a mesh to the part left of the
+// directed line through a and b, like ClipMesh. Vertices on the line take
+// their height from the face edge they cut.
+func ClipMeshHalfPlane(vertices []Coord, faces [][3]int, a, b Coord) ([]Coord, [][3]int, error) {
+	if err := validateLine(a, b); err != nil {
+		return nil, nil, err
+	}
+	if len(faces) == 0 {
+		return nil, nil, nil
+	}
+	if len(vertices) == 0 {
+		// Without vertices there is nothing to size the half-plane by,
+		// and the first face already refers to a missing vertex
+		return nil, nil, validateFace(vertices, 0, faces[0])
+	}
+
+	// The half-plane is convex, so every face streams through the
+	// Sutherland–Hodgman clipper
+	left, _ := halfPlanes(vertices, a, b)
+	return clipMeshFaces(context.Background(), vertices, faces, Region{Exterior: left}, nil)
+}
+
+// SplitByLine cuts poly along the line through a and b and returns the
+// pieces left and right of it, looking from a towards b. Either side is empty
+// when the line misses the polygon.
+func SplitByLine(poly Polygon, a, b Coord) (left, right Polygons, err error) {
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, nil, err
+	}
+	if err := validateLine(a, b); err != nil {
+		return nil, nil, err
+	}
+
+	// Both halves share the cutting edge, so the pieces meet exactly
+	l, r := halfPlanes(poly, a, b)
+	if left, err = clipToHalfPlane(poly, l); err != nil {
+		return nil, nil, err
+	}
+	if right, err = clipToHalfPlane(poly, r); err != nil {
+		return nil, nil, err
+	}
+	return left, right, nil
+}
+
+// clipToHalfPlane intersects poly with a rectangle from halfPlanes. The
+// tolerance only follows poly, as the rectangle may reach far beyond it.
+func clipToHalfPlane(poly, half Polygon) (Polygons, error) {
+	return scaledTolerance(Polygons{poly}).overlay(context.Background(), Polygons{poly}, Polygons{half}, opIntersection, EvenOdd)
+}
+
+func validateLine(a, b Coord) error {
+	if a[0] == b[0] && a[1] == b[1] {
+		return &DegenerateInputError{Name: "line", Polygon: Polygon{a, b}, Min: 2}
+	}
+	return nil
+}
+
+// halfPlanes returns two counter-clockwise rectangles sharing an edge on the
+// line through a and b, that cover the parts of poly left and right of it
+func halfPlanes(poly Polygon, a, b Coord) (left, right Polygon) {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l := math.Hypot(dx, dy)
+	dx, dy = dx/l, dy/l
+
+	// Extent of poly along the line and to either side of it, with a margin
+	// so that the rectangle edges off the line stay clear of the polygon
+	bb := polygonBounds(poly)
+	margin := math.Max(bb.maxX-bb.minX, bb.maxY-bb.minY)
+	lo, hi, side := math.Inf(1), math.Inf(-1), 0.0
+	for _, c := range []Coord{{bb.minX, bb.minY}, {bb.maxX, bb.minY}, {bb.maxX, bb.maxY}, {bb.minX, bb.maxY}} {
+		along := (c[0]-a[0])*dx + (c[1]-a[1])*dy
+		lo, hi = math.Min(lo, along), math.Max(hi, along)
+		side = math.Max(side, math.Abs(dx*(c[1]-a[1])-dy*(c[0]-a[0])))
+	}
+	lo, hi, side = lo-margin, hi+margin, side+margin
+
+	p, q := Coord{a[0] + lo*dx, a[1] + lo*dy}, Coord{a[0] + hi*dx, a[1] + hi*dy}
+	nx, ny := -dy*side, dx*side
+	left = Polygon{p, q, {q[0] + nx, q[1] + ny}, {p[0] + nx, p[1] + ny}}
+	right = Polygon{q, p, {p[0] - nx, p[1] - ny}, {q[0] - nx, q[1] - ny}}
+	return left, right
+}
+
+// SplitByPolyline cuts poly along the polyline cut and returns the pieces it
+// separates poly into, oriented as in Union. Parts of cut that end inside
+// poly separate nothing and are ignored, so poly comes back as its only
+// piece when cut does not cross it from boundary to boundary. Where the cut
+// crosses the boundary, the new vertices take their height from the polygon
+// edge.
+func SplitByPolyline(poly Polygon, cut []Coord) (Polygons, error) {
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, err
+	}
+	if err := validatePolyline("cut", cut); err != nil {
+		return nil, err
+	}
+
+	tol := scaledTolerance(Polygons{poly, cut})
+	idGen := &idGenerator{}
+
+	line := make(Polygon, 0, len(cut))
+	for _, c := range cut {
+		if len(line) == 0 || !tol.coordsEqual(line[len(line)-1], c) {
+			line = append(line, c)
+		}
+	}
+	if len(line) < 2 {
+		return Polygons{orientRing(poly, true)}, nil
+	}
+
+	// The cut is noded like a clip ring without its closing edge
+	rings := [][]*node{
+		makeShapeWithID(tol.dedupRing(poly), true, idGen),
+		makeShapeWithID(line, false, idGen),
+	}
+	if err := tol.mergePairwise(rings, nil); err != nil {
+		return nil, err
+	}
+	ringEdges := [][][]*node{edges(rings[0]), edges(rings[1])[:len(line)-1]}
+	ringEdges, err := tol.splitPairwise(context.Background(), rings, ringEdges, idGen)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cut may run back over itself, which cancels its count on the
+	// edges it retraces, so the pieces it covers are looked up by node pair
+	onCut := make(map[[2]int]bool, len(ringEdges[1]))
+	for _, p := range ringEdges[1] {
+		onCut[[2]int{p[0].id, p[1].id}] = true
+		onCut[[2]int{p[1].id, p[0].id}] = true
+	}
+
+	// Pieces of the cut inside poly are walked in both directions, so that
+	// they end up in the rings on either side of them
+	graph := buildOverlayEdges(ringEdges, []int{0, 1})
+	var cuts []*overlayEdge
+	for _, e := range graph {
+		u, v := e.from.coord, e.to.coord
+		mid := Coord{(u[0] + v[0]) / 2, (u[1] + v[1]) / 2}
+		if e.count[0] == 0 && onCut[[2]int{e.from.id, e.to.id}] && isInsidePolygon(mid, poly, EvenOdd) {
+			cuts = append(cuts, e)
+		}
+		e.count[1] = 0
+	}
+	probes, err := windings(graph, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := selectOverlayEdges(graph, probes, opUnion, EvenOdd)
+
+	cuts = pruneDangling(result, cuts)
+	if len(cuts) == 0 {
+		return Polygons{orientRing(poly, true)}, nil
+	}
+	for _, e := range cuts {
+		result = append(result, e, &overlayEdge{from: e.to, to: e.from})
+	}
+
+	out, err := tol.traceRings(context.Background(), result, traceLimit(len(poly)+len(line)))
+	switch e := err.(type) {
+	case *LoopNotClosedError:
+		e.Target, e.Clip = Polygons{poly}, Polygons{line}
+	case *IterationLimitError:
+		e.Target, e.Clip = Polygons{poly}, Polygons{line}
+	}
+	return out, err
+}
+
+// pruneDangling drops the cut edges that lead to a dead end, repeatedly, so
+// that only cuts running from boundary to boundary remain
+func pruneDangling(boundary, cuts []*overlayEdge) []*overlayEdge {
+	degree := make(map[*node]int)
+	for _, e := range boundary {
+		degree[e.from]++
+		degree[e.to]++
+	}
+	for _, e := range cuts {
+		degree[e.from]++
+		degree[e.to]++
+	}
+
+	for changed := true; changed; {
+		changed = false
+		kept := cuts[:0]
+		for _, e := range cuts {
+			if degree[e.from] < 2 || degree[e.to] < 2 {
+				degree[e.from]--
+				degree[e.to]--
+				changed = true
+				continue
+			}
+			kept = append(kept, e)
+		}
+		cuts = kept
+	}
+	return cuts
+}
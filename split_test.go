@@ -0,0 +1,171 @@
+package clippoly
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestSplitByLine(t *testing.T) {
+	// U shape opening upwards, 3 wide and 3 high with a 1x2 notch
+	u := Polygon{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}}
+
+	tests := []struct {
+		name                string
+		poly                Polygon
+		a, b                Coord
+		leftArea, rightArea float64
+		leftRings           int
+	}{
+		{name: "diagonal", poly: square(0, 0, 2), a: Coord{0, 0}, b: Coord{2, 2}, leftArea: 2, rightArea: 2, leftRings: 1},
+		{name: "through_notch", poly: u, a: Coord{3, 2}, b: Coord{0, 2}, leftArea: 5, rightArea: 2, leftRings: 1},
+		{name: "across_arms", poly: u, a: Coord{0, 2}, b: Coord{3, 2}, leftArea: 2, rightArea: 5, leftRings: 2},
+		{name: "along_edge", poly: square(0, 0, 2), a: Coord{0, 0}, b: Coord{1, 0}, leftArea: 4, rightArea: 0, leftRings: 1},
+		{name: "misses", poly: square(0, 0, 2), a: Coord{5, 0}, b: Coord{5, 1}, leftArea: 4, rightArea: 0, leftRings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right, err := SplitByLine(tt.poly, tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("split: %v", err)
+			}
+			if math.Abs(totalArea(left)-tt.leftArea) > 1e-9 || math.Abs(totalArea(right)-tt.rightArea) > 1e-9 {
+				t.Fatalf("areas = %.3f, %.3f, want %.3f, %.3f", totalArea(left), totalArea(right), tt.leftArea, tt.rightArea)
+			}
+			if len(left) != tt.leftRings {
+				t.Fatalf("got %d left rings, want %d: %v", len(left), tt.leftRings, left)
+			}
+
+			half, err := ClipHalfPlane(tt.poly, tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("clip half plane: %v", err)
+			}
+			if math.Abs(totalArea(half)-tt.leftArea) > 1e-9 {
+				t.Fatalf("ClipHalfPlane area = %.3f, want %.3f", totalArea(half), tt.leftArea)
+			}
+		})
+	}
+}
+
+func TestSplitByLineInterpolatesZ(t *testing.T) {
+	// a ramp rising along x
+	poly := Polygon{{0, 0, 0}, {4, 0, 8}, {4, 2, 8}, {0, 2, 0}}
+	left, right, err := SplitByLine(poly, Coord{1, -1}, Coord{1, 3})
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	for _, ring := range append(left, right...) {
+		for _, v := range ring {
+			if math.Abs(v[2]-2*v[0]) > 1e-9 {
+				t.Fatalf("vertex %v is off the ramp", v)
+			}
+		}
+	}
+}
+
+func TestClipMeshHalfPlane(t *testing.T) {
+	// a ramp rising along x, cut by a diagonal through it
+	vertices := []Coord{{0, 0, 0}, {4, 0, 8}, {4, 4, 8}, {0, 4, 0}}
+	faces := [][3]int{{0, 1, 2}, {0, 2, 3}}
+
+	verts, tris, err := ClipMeshHalfPlane(vertices, faces, Coord{0, 1}, Coord{4, 3})
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+
+	// left of the line lies the part above y = 1 + x/2
+	area := 0.0
+	for _, f := range tris {
+		tri := Polygon{verts[f[0]], verts[f[1]], verts[f[2]]}
+		if signedArea(tri) <= 0 {
+			t.Fatalf("face %v is not counter-clockwise", tri)
+		}
+		area += signedArea(tri)
+		for _, v := range tri {
+			if v[1] < 1+v[0]/2-1e-9 {
+				t.Fatalf("vertex %v lies right of the line", v)
+			}
+			if math.Abs(v[2]-2*v[0]) > 1e-9 {
+				t.Fatalf("vertex %v is off the ramp", v)
+			}
+		}
+	}
+	if math.Abs(area-8) > 1e-9 {
+		t.Fatalf("area = %g, want 8", area)
+	}
+
+	if _, _, err := ClipMeshHalfPlane(vertices, faces, Coord{1, 1}, Coord{1, 1}); err == nil {
+		t.Fatalf("expected an error for a line through one point")
+	}
+}
+
+func TestSplitByLineDegenerate(t *testing.T) {
+	if _, _, err := SplitByLine(square(0, 0, 1), Coord{1, 1}, Coord{1, 1}); err == nil {
+		t.Fatalf("expected an error for a line through one point")
+	}
+}
+
+func TestSplitByPolyline(t *testing.T) {
+	u := Polygon{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}}
+
+	tests := []struct {
+		name  string
+		poly  Polygon
+		cut   []Coord
+		areas []float64
+	}{
+		{name: "straight", poly: square(0, 0, 2), cut: []Coord{{1, -1}, {1, 3}}, areas: []float64{2, 2}},
+		{name: "bent", poly: square(0, 0, 2), cut: []Coord{{-1, 1}, {1, 1}, {1, 3}}, areas: []float64{1, 3}},
+		{name: "through_vertices", poly: square(0, 0, 2), cut: []Coord{{0, 0}, {2, 2}}, areas: []float64{2, 2}},
+		{name: "both_arms", poly: u, cut: []Coord{{-1, 2}, {4, 2}}, areas: []float64{1, 1, 5}},
+		{name: "dangling_tail", poly: square(0, 0, 2), cut: []Coord{{1, -1}, {1, 3}, {1.5, 1}}, areas: []float64{2, 2}},
+		{name: "doubles_back", poly: square(0, 0, 2), cut: []Coord{{-1, 1}, {3, 1}, {1, 1}}, areas: []float64{2, 2}},
+		{name: "ends_inside", poly: square(0, 0, 2), cut: []Coord{{-1, 1}, {1, 1}}, areas: []float64{4}},
+		{name: "misses", poly: square(0, 0, 2), cut: []Coord{{3, 0}, {3, 2}}, areas: []float64{4}},
+		{name: "straight_clockwise", poly: orientRing(square(0, 0, 2), false), cut: []Coord{{1, -1}, {1, 3}}, areas: []float64{2, 2}},
+		{name: "misses_clockwise", poly: orientRing(square(0, 0, 2), false), cut: []Coord{{3, 0}, {3, 2}}, areas: []float64{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pieces, err := SplitByPolyline(tt.poly, tt.cut)
+			if err != nil {
+				t.Fatalf("split: %v", err)
+			}
+			if len(pieces) != len(tt.areas) {
+				t.Fatalf("got %d pieces, want %d: %v", len(pieces), len(tt.areas), pieces)
+			}
+			areas := make([]float64, len(pieces))
+			for i, p := range pieces {
+				if areas[i] = signedArea(p); areas[i] <= 0 {
+					t.Fatalf("piece %v is not counter-clockwise", p)
+				}
+			}
+			sort.Float64s(areas)
+			for i := range areas {
+				if math.Abs(areas[i]-tt.areas[i]) > 1e-9 {
+					t.Fatalf("piece areas = %v, want %v", areas, tt.areas)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByPolylineInterpolatesZ(t *testing.T) {
+	poly := Polygon{{0, 0, 0}, {4, 0, 8}, {4, 2, 8}, {0, 2, 0}}
+	pieces, err := SplitByPolyline(poly, []Coord{{1, -1}, {3, 3}})
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if len(pieces) != 2 {
+		t.Fatalf("got %d pieces, want 2: %v", len(pieces), pieces)
+	}
+	for _, ring := range pieces {
+		for _, v := range ring {
+			if math.Abs(v[2]-2*v[0]) > 1e-9 {
+				t.Fatalf("vertex %v is off the ramp", v)
+			}
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package clippoly
+
+import (
+	"context"
+	"sort"
+)
+
+// sweepThreshold is the number of input vertices from which AutoBackend
+// nodes with the sweep instead of comparing every edge with every edge
+const sweepThreshold = 512
+
+// useSweep reports whether rings with n vertices in total are noded and
+// classified by the sweep
+func (tol tolerance) useSweep(n int) bool {
+	switch tol.backend {
+	case GraphBackend:
+		return false
+	case SweepBackend:
+		return true
+	}
+	return n >= sweepThreshold
+}
+
+// sweepSegment is an input edge together with the nodes at which other
+// edges touch or cross it
+type sweepSegment struct {
+	a, b   *node
+	ring   int
+	bounds bounds
+	splits []*node
+}
+
+// nodeSweep is the sweep counterpart of nodePairwise and produces the same
+// graph: nodes within tolerance are merged, edges are split at vertices lying
+// on them, and then the pieces are split where they cross each other.
+//
+// Edges are paired up by sweepPairs, so only edges whose bounding boxes touch
+// are compared. For n vertices, k crossings and p pairs of edges with
+// touching bounding boxes this takes O((n+k+p) log n).
+func (tol tolerance) nodeSweep(ctx context.Context, rings [][]*node, idGen *idGenerator) ([][][]*node, error) {
+	tol.mergeNodesSweep(rings)
+
+	var segs []*sweepSegment
+	for r, ring := range rings {
+		for _, e := range edges(ring) {
+			if e[0] != e[1] {
+				segs = append(segs, newSweepSegment(e[0], e[1], r))
+			}
+		}
+	}
+
+	err := tol.sweepPairs(ctx, segs, func(s, t *sweepSegment) {
+		tol.splitAtVertex(s, t.a)
+		tol.splitAtVertex(s, t.b)
+		tol.splitAtVertex(t, s.a)
+		tol.splitAtVertex(t, s.b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Crossings are searched between the pieces, as the edges run through
+	// the vertices they were split at. This matters in integer mode, where
+	// an edge is routed through every vertex whose unit square it passes.
+	var pieces []*sweepSegment
+	for _, s := range segs {
+		pieces = s.split(pieces, func(n *node) *node { return n })
+	}
+
+	err = tol.sweepPairs(ctx, pieces, func(s, t *sweepSegment) {
+		// The edge of the earlier ring gives the crossing its height, so
+		// that target edges do as in intersect
+		a, b := s, t
+		if b.ring < a.ring {
+			a, b = b, a
+		}
+		if n := tol.findIntersect([]*node{a.a, a.b}, []*node{b.a, b.b}); n != nil {
+			n.id = idGen.Next()
+			s.splits = append(s.splits, n)
+			t.splits = append(t.splits, n)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Where several pieces cross in one point, their crossings may differ
+	// by rounding. Each is replaced by the first one on all pieces.
+	alias := make(map[*node]*node)
+	for _, s := range pieces {
+		s.sortSplits()
+		for i := 1; i < len(s.splits); i++ {
+			p, q := s.splits[i-1], s.splits[i]
+			if tol.coordsEqual(p.coord, q.coord) {
+				if p.id > q.id {
+					p, q = q, p
+				}
+				alias[q] = p
+			}
+		}
+	}
+	resolve := func(n *node) *node {
+		for {
+			m, ok := alias[n]
+			if !ok {
+				return n
+			}
+			n = m
+		}
+	}
+
+	var noded []*sweepSegment
+	for _, s := range pieces {
+		noded = s.split(noded, resolve)
+	}
+
+	ringEdges := make([][][]*node, len(rings))
+	for _, s := range noded {
+		ringEdges[s.ring] = append(ringEdges[s.ring], []*node{s.a, s.b})
+	}
+	return ringEdges, nil
+}
+
+func newSweepSegment(a, b *node, ring int) *sweepSegment {
+	return &sweepSegment{
+		a: a, b: b, ring: ring,
+		bounds: polygonBounds(Polygon{a.coord, b.coord}),
+	}
+}
+
+// split appends the pieces of s between its split nodes to pieces, mapping
+// every split node through resolve
+func (s *sweepSegment) split(pieces []*sweepSegment, resolve func(*node) *node) []*sweepSegment {
+	s.sortSplits()
+	prev := s.a
+	for _, n := range s.splits {
+		n = resolve(n)
+		if n == prev || n == s.a || n == s.b {
+			continue
+		}
+		pieces = append(pieces, newSweepSegment(prev, n, s.ring))
+		prev = n
+	}
+	return append(pieces, newSweepSegment(prev, s.b, s.ring))
+}
+
+// sweepPairs calls fn for every pair of segments whose bounding boxes touch
+// within tolerance. The segment that comes first in segs is passed first, as
+// the pairwise noding does.
+//
+// Segments are swept in order of their left end and leave the sweep in order
+// of their right end. The y ranges of the segments the sweep line cuts are
+// kept in an interval tree, so each segment only meets the segments whose
+// bounding boxes it touches.
+func (tol tolerance) sweepPairs(ctx context.Context, segs []*sweepSegment, fn func(s, t *sweepSegment)) error {
+	byMin, byMax := make([]int, len(segs)), make([]int, len(segs))
+	for i := range byMin {
+		byMin[i], byMax[i] = i, i
+	}
+	sort.Slice(byMin, func(i, j int) bool { return segs[byMin[i]].bounds.minX < segs[byMin[j]].bounds.minX })
+	sort.Slice(byMax, func(i, j int) bool { return segs[byMax[i]].bounds.maxX < segs[byMax[j]].bounds.maxX })
+
+	var active intervalTree
+	entries := make([]*treapNode[interval], len(segs))
+	gone := 0
+	for k, j := range byMin {
+		if k%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		s := segs[j]
+		for ; gone < len(byMax) && segs[byMax[gone]].bounds.maxX < s.bounds.minX-tol.eps; gone++ {
+			active.remove(entries[byMax[gone]])
+		}
+
+		active.overlapping(s.bounds.minY-tol.eps, s.bounds.maxY+tol.eps, func(iv interval) {
+			if i, t := iv.id, segs[iv.id]; i < j {
+				fn(t, s)
+			} else {
+				fn(s, t)
+			}
+		})
+		entries[j] = active.insert(s.bounds.minY, s.bounds.maxY, j, [2]int{})
+	}
+	return nil
+}
+
+// mergeNodesSweep replaces every node of rings by the first node within
+// tolerance of it, like mergeCoincidentNodes does pairwise
+func (tol tolerance) mergeNodesSweep(rings [][]*node) {
+	type ref struct{ ring, index int }
+	var refs []ref
+	for r, ring := range rings {
+		for i := range ring {
+			refs = append(refs, ref{r, i})
+		}
+	}
+	at := func(f ref) *node { return rings[f.ring][f.index] }
+	sort.Slice(refs, func(i, j int) bool {
+		return at(refs[i]).coord[0] < at(refs[j]).coord[0]
+	})
+
+	canon := make(map[*node]*node)
+	root := func(n *node) *node {
+		for {
+			m, ok := canon[n]
+			if !ok {
+				return n
+			}
+			n = m
+		}
+	}
+	for i, fi := range refs {
+		a := at(fi)
+		for _, fj := range refs[i+1:] {
+			b := at(fj)
+			if b.coord[0]-a.coord[0] >= tol.eps {
+				break
+			}
+			if !tol.coordsEqual(a.coord, b.coord) {
+				continue
+			}
+			ra, rb := root(a), root(b)
+			if ra == rb {
+				continue
+			}
+			// Nodes are numbered in ring order, so the lower id is the one
+			// the pairwise merge keeps
+			if ra.id > rb.id {
+				ra, rb = rb, ra
+			}
+			canon[rb] = ra
+		}
+	}
+
+	for r, ring := range rings {
+		for i, n := range ring {
+			rings[r][i] = root(n)
+		}
+	}
+}
+
+func (tol tolerance) splitAtVertex(s *sweepSegment, p *node) {
+	if p == s.a || p == s.b || tol.coordsEqual(p.coord, s.a.coord) || tol.coordsEqual(p.coord, s.b.coord) {
+		return
+	}
+	if tol.snapsTo(p.coord, s.a.coord, s.b.coord) {
+		s.splits = append(s.splits, p)
+	}
+}
+
+// sortSplits orders the split nodes of s from a to b and drops repeats
+func (s *sweepSegment) sortSplits() {
+	a, b := s.a.coord, s.b.coord
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	along := func(n *node) float64 {
+		return (n.coord[0]-a[0])*dx + (n.coord[1]-a[1])*dy
+	}
+	sort.Slice(s.splits, func(i, j int) bool {
+		return along(s.splits[i]) < along(s.splits[j])
+	})
+
+	out := s.splits[:0]
+	for _, n := range s.splits {
+		if len(out) > 0 && out[len(out)-1] == n {
+			continue
+		}
+		out = append(out, n)
+	}
+	s.splits = out
+}
+
+// windingsSweep returns for every edge of graph the target and clip winding
+// at its midpoint, ignoring the edge itself. Only edges spanning the height
+// of a midpoint can cross the ray running right from it, so midpoints and
+// edges are swept bottom to top. The x ranges of the edges the sweep line
+// cuts are kept in an interval tree: edges lying wholly right of a midpoint
+// cross its ray and are summed by the tree, and only the edges whose x range
+// holds the midpoint are tested one by one.
+func windingsSweep(ctx context.Context, graph []*overlayEdge) ([][2]int, error) {
+	probes := make([][2]int, len(graph))
+
+	var queries, spans []int
+	for i, e := range graph {
+		if e.count == [2]int{} {
+			continue
+		}
+		queries = append(queries, i)
+		if e.from.coord[1] != e.to.coord[1] {
+			spans = append(spans, i)
+		}
+	}
+	midY := func(i int) float64 { return (graph[i].from.coord[1] + graph[i].to.coord[1]) / 2 }
+	minY := func(i int) float64 { return min(graph[i].from.coord[1], graph[i].to.coord[1]) }
+	maxY := func(i int) float64 { return max(graph[i].from.coord[1], graph[i].to.coord[1]) }
+	sort.Slice(queries, func(i, j int) bool { return midY(queries[i]) < midY(queries[j]) })
+	byMax := append([]int(nil), spans...)
+	sort.Slice(spans, func(i, j int) bool { return minY(spans[i]) < minY(spans[j]) })
+	sort.Slice(byMax, func(i, j int) bool { return maxY(byMax[i]) < maxY(byMax[j]) })
+
+	// An edge cuts the sweep line from its lower end up to, but not
+	// including, its upper end, as crossing counts it
+	var active intervalTree
+	entries := make([]*treapNode[interval], len(graph))
+	next, gone := 0, 0
+	for k, q := range queries {
+		if k%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		y := midY(q)
+		for ; next < len(spans) && minY(spans[next]) <= y; next++ {
+			f := graph[spans[next]]
+			// Seen from a point to its left, an upward edge counts once
+			// and a downward one minus once
+			w := f.count
+			if f.to.coord[1] < f.from.coord[1] {
+				w = [2]int{-w[0], -w[1]}
+			}
+			entries[spans[next]] = active.insert(min(f.from.coord[0], f.to.coord[0]), max(f.from.coord[0], f.to.coord[0]), spans[next], w)
+		}
+		for ; gone < len(byMax) && maxY(byMax[gone]) <= y; gone++ {
+			active.remove(entries[byMax[gone]])
+		}
+
+		e := graph[q]
+		u, v := e.from.coord, e.to.coord
+		mid := Coord{(u[0] + v[0]) / 2, (u[1] + v[1]) / 2}
+		probes[q] = active.sumAbove(mid[0])
+		active.overlapping(mid[0], mid[0], func(iv interval) {
+			if iv.id == q {
+				return
+			}
+			f := graph[iv.id]
+			c := crossing(mid, f.from.coord, f.to.coord)
+			probes[q][0] += c * f.count[0]
+			probes[q][1] += c * f.count[1]
+		})
+	}
+
+	return probes, nil
+}
+
+// intervalTree holds the intervals [lo, hi] that a sweep line cuts in a
+// treap ordered by lo. Every node also keeps the largest hi and the total
+// weight of its subtree, so that the intervals overlapping a range are found
+// in O(log n) each and the weight of the intervals starting beyond a point
+// is summed in O(log n).
+type intervalTree struct {
+	treap[interval]
+}
+
+// interval is an entry of an intervalTree
+type interval struct {
+	lo, hi float64
+	id     int
+	weight [2]int
+
+	maxHi float64
+	sum   [2]int
+}
+
+// before orders intervals by lo, and intervals starting together by id
+func (a interval) before(b interval) bool {
+	return a.lo < b.lo || (a.lo == b.lo && a.id < b.id)
+}
+
+func updateInterval(n *treapNode[interval]) {
+	a := &n.value
+	a.maxHi, a.sum = a.hi, a.weight
+	for _, c := range []*treapNode[interval]{n.left, n.right} {
+		if c != nil {
+			a.maxHi = max(a.maxHi, c.value.maxHi)
+			a.sum[0] += c.value.sum[0]
+			a.sum[1] += c.value.sum[1]
+		}
+	}
+}
+
+// insert adds the interval [lo, hi] and returns its entry for remove
+func (t *intervalTree) insert(lo, hi float64, id int, weight [2]int) *treapNode[interval] {
+	t.update = updateInterval
+	n := t.newNode(interval{lo: lo, hi: hi, id: id, weight: weight})
+	left, right := t.split(t.root, func(v interval) bool { return v.before(n.value) })
+	t.root = t.merge(t.merge(left, n), right)
+	return n
+}
+
+func (t *intervalTree) remove(n *treapNode[interval]) {
+	if n == nil {
+		return
+	}
+	left, rest := t.split(t.root, func(v interval) bool { return v.before(n.value) })
+	_, right := t.split(rest, func(v interval) bool { return !n.value.before(v) })
+	t.root = t.merge(left, right)
+}
+
+// overlapping calls fn for every interval reaching into [lo, hi], in order
+func (t *intervalTree) overlapping(lo, hi float64, fn func(iv interval)) {
+	var visit func(n *treapNode[interval])
+	visit = func(n *treapNode[interval]) {
+		if n == nil || n.value.maxHi < lo {
+			return
+		}
+		visit(n.left)
+		if n.value.lo > hi {
+			return
+		}
+		if n.value.hi >= lo {
+			fn(n.value)
+		}
+		visit(n.right)
+	}
+	visit(t.root)
+}
+
+// sumAbove returns the total weight of the intervals starting beyond x
+func (t *intervalTree) sumAbove(x float64) [2]int {
+	var sum [2]int
+	for n := t.root; n != nil; {
+		if n.value.lo <= x {
+			n = n.right
+			continue
+		}
+		sum[0] += n.value.weight[0]
+		sum[1] += n.value.weight[1]
+		if n.right != nil {
+			sum[0] += n.right.value.sum[0]
+			sum[1] += n.right.value.sum[1]
+		}
+		n = n.left
+	}
+	return sum
+}
@@ -0,0 +1,163 @@
+package clippoly
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// wobblyCircle returns a counter-clockwise ring of n vertices around c whose
+// radius varies over several scales, like a coastline
+func wobblyCircle(rng *rand.Rand, cx, cy, r float64, n int) Polygon {
+	var phase [3]float64
+	for i := range phase {
+		phase[i] = 2 * math.Pi * rng.Float64()
+	}
+	ring := make(Polygon, n)
+	for i := range ring {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		d := r * (1 + 0.05*math.Sin(7*a+phase[0]) + 0.02*math.Sin(31*a+phase[1]) + 0.005*math.Sin(211*a+phase[2]))
+		ring[i] = Coord{cx + d*math.Cos(a), cy + d*math.Sin(a), float64(i)}
+	}
+	return ring
+}
+
+// comb returns a counter-clockwise ring with n teeth one unit wide and
+// height units high, whose edges all span the height of the comb
+func comb(n int, height float64) Polygon {
+	var ring Polygon
+	for i := 0; i < n; i++ {
+		x := float64(2 * i)
+		ring = append(ring, Coord{x, 0}, Coord{x, height}, Coord{x + 1, height}, Coord{x + 1, 1})
+	}
+	return orientRing(append(ring, Coord{float64(2 * n), 1}, Coord{float64(2 * n), 0}), true)
+}
+
+func TestSweepMatchesGraph(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	pentagram := Polygon{{0, 3}, {2, -3}, {-3, 1}, {3, 1}, {-2, -3}}
+
+	tests := []struct {
+		name   string
+		target Polygons
+		clip   Polygons
+		rule   FillRule
+	}{
+		{name: "overlapping_squares", target: Polygons{square(0, 0, 2)}, clip: Polygons{square(1, 1, 2)}},
+		{name: "shared_edge", target: Polygons{square(0, 0, 2)}, clip: Polygons{square(2, 0.5, 1)}},
+		{name: "contained", target: Polygons{square(0, 0, 4)}, clip: Polygons{square(1, 1, 1)}},
+		{name: "hole", target: Polygons{square(0, 0, 4), orientRing(square(1, 1, 2), false)}, clip: Polygons{square(2, -1, 4)}},
+		{name: "pentagram_even_odd", target: Polygons{pentagram}, clip: Polygons{square(-1.2, -1.1, 2)}, rule: EvenOdd},
+		{name: "pentagram_non_zero", target: Polygons{pentagram}, clip: Polygons{square(-1.2, -1.1, 2)}, rule: NonZero},
+		{name: "wobbly", target: Polygons{wobblyCircle(rng, 0, 0, 10, 300)}, clip: Polygons{wobblyCircle(rng, 5, 3, 8, 200)}},
+		{name: "comb", target: Polygons{comb(100, 50)}, clip: Polygons{{{-1, 20}, {250, 10}, {250, 40}}}},
+		// the clip crosses the edge both squares share in one point
+		{name: "crossing_shared_edge", target: Polygons{{{0, 0}, {1, 0}, {1, 2}, {0, 2}}, {{1, 0}, {2, 0}, {2, 2}, {1, 2}}}, clip: Polygons{{{-1, -1}, {3, 2.5}, {-1, 2.5}}}, rule: NonZero},
+		// a zero-area target whose edges overlap, crossed by the clip
+		{name: "collinear_target", target: Polygons{{{0, 0}, {1, 1}, {2, 2}}}, clip: Polygons{{{0.5, -1}, {1.5, -1}, {1.5, 3}, {0.5, 3}}}},
+	}
+
+	ops := []struct {
+		name string
+		op   boolOp
+	}{
+		{"intersection", opIntersection},
+		{"union", opUnion},
+		{"difference", opDifference},
+		{"xor", opXor},
+	}
+
+	for _, tt := range tests {
+		for _, o := range ops {
+			t.Run(tt.name+"_"+o.name, func(t *testing.T) {
+				tol := scaledTolerance(tt.target, tt.clip)
+				tol.backend = GraphBackend
+				want, err := tol.overlay(context.Background(), tt.target, tt.clip, o.op, tt.rule)
+				if err != nil {
+					t.Fatalf("graph: %v", err)
+				}
+				tol.backend = SweepBackend
+				got, err := tol.overlay(context.Background(), tt.target, tt.clip, o.op, tt.rule)
+				if err != nil {
+					t.Fatalf("sweep: %v", err)
+				}
+
+				if len(got) != len(want) {
+					t.Fatalf("sweep gave %d rings, graph %d", len(got), len(want))
+				}
+				if math.Abs(totalArea(got)-totalArea(want)) > 1e-9 {
+					t.Fatalf("sweep area = %.9f, graph area = %.9f", totalArea(got), totalArea(want))
+				}
+				vertices := 0
+				for i := range got {
+					vertices += len(got[i]) - len(want[i])
+				}
+				if vertices != 0 {
+					t.Fatalf("sweep rings %v differ from graph rings %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestSweepLargePolygons(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	a := wobblyCircle(rng, 0, 0, 1000, 20000)
+	b := wobblyCircle(rng, 700, 300, 800, 20000)
+
+	union, err := Union(a, b)
+	if err != nil {
+		t.Fatalf("union: %v", err)
+	}
+	inter, err := ClipWithOptions(a, b, ClipOptions{Output: OutputRings, Backend: SweepBackend})
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	diff, err := Difference(a, b)
+	if err != nil {
+		t.Fatalf("difference: %v", err)
+	}
+
+	// the union splits into the intersection and both differences
+	other, err := Difference(b, a)
+	if err != nil {
+		t.Fatalf("difference: %v", err)
+	}
+	sum := totalArea(inter) + totalArea(diff) + totalArea(other)
+	if math.Abs(totalArea(union)-sum) > 1e-6*totalArea(union) {
+		t.Fatalf("union area %.3f, pieces add up to %.3f", totalArea(union), sum)
+	}
+	if got, want := totalArea(inter)+totalArea(diff), signedArea(a); math.Abs(got-want) > 1e-6*want {
+		t.Fatalf("intersection and difference add up to %.3f, want %.3f", got, want)
+	}
+
+	tris, err := ClipWithOptions(a, b, ClipOptions{Backend: SweepBackend})
+	if err != nil {
+		t.Fatalf("clip triangles: %v", err)
+	}
+	if math.Abs(totalArea(tris)-totalArea(inter)) > 1e-6*totalArea(inter) {
+		t.Fatalf("triangles cover %.3f, rings %.3f", totalArea(tris), totalArea(inter))
+	}
+}
+
+func TestSweepLargeComb(t *testing.T) {
+	// Every edge of the comb spans its full height, so the sweep line cuts
+	// all of them at once
+	c := comb(5000, 100)
+	cut := Polygon{{-1, 50}, {20000, 40}, {20000, 60}}
+
+	got, err := ClipWithOptions(c, cut, ClipOptions{Backend: SweepBackend})
+	if err != nil {
+		t.Fatalf("clip: %v", err)
+	}
+	// The cut widens linearly from its tip at x = -1 to 20 at x = 20000, and
+	// every tooth takes its width at the middle of the tooth
+	var want float64
+	for i := 0; i < 5000; i++ {
+		want += 20 * (float64(2*i) + 1.5) / 20001
+	}
+	if math.Abs(totalArea(got)-want) > 1e-6*want {
+		t.Fatalf("clipped area = %.6f, want %.6f", totalArea(got), want)
+	}
+}
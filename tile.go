@@ -0,0 +1,188 @@
+package clippoly
+
+import (
+	"fmt"
+	"math"
+)
+
+// TileIndex identifies a tile of a Tiler by column and row. Tile (X, Y)
+// covers the square from Origin + (X, Y)·Size to Origin + (X+1, Y+1)·Size.
+type TileIndex struct {
+	X, Y int
+}
+
+// Tiler cuts polygons and meshes into a regular grid of square tiles, for
+// example to stream them in pieces.
+type Tiler struct {
+	// Origin is the lower left corner of tile (0, 0). Its height is not
+	// used.
+	Origin Coord
+	// Size is the width and height of a tile.
+	Size float64
+}
+
+// MeshTile is the part of a mesh that falls in one tile, with its own
+// shared vertices as returned by ClipMesh.
+type MeshTile struct {
+	Vertices []Coord
+	Faces    [][3]int
+}
+
+// maxTiles is the most tiles a single polygon or face may reach into. It
+// keeps a shape that is huge next to the tile size from running through
+// billions of tiles, or past the range of the tile indices.
+const maxTiles = 1 << 20
+
+func (t Tiler) validate() error {
+	if !(t.Size > 0) || math.IsInf(t.Size, 0) {
+		return fmt.Errorf("tile size must be positive and finite, got %g", t.Size)
+	}
+	if math.IsInf(t.Origin[0], 0) || math.IsNaN(t.Origin[0]) || math.IsInf(t.Origin[1], 0) || math.IsNaN(t.Origin[1]) {
+		return fmt.Errorf("tile origin must be finite, got (%g, %g)", t.Origin[0], t.Origin[1])
+	}
+	return nil
+}
+
+// bounds returns the square covered by tile i
+func (t Tiler) bounds(i TileIndex) bounds {
+	return bounds{
+		minX: t.Origin[0] + float64(i.X)*t.Size,
+		minY: t.Origin[1] + float64(i.Y)*t.Size,
+		maxX: t.Origin[0] + float64(i.X+1)*t.Size,
+		maxY: t.Origin[1] + float64(i.Y+1)*t.Size,
+	}
+}
+
+// tiles returns the range of tiles that b reaches into. It fails when b is
+// not finite or reaches into more than maxTiles tiles.
+func (t Tiler) tiles(b bounds) (lo, hi TileIndex, err error) {
+	if !b.finite() {
+		return lo, hi, fmt.Errorf("bounds (%g, %g)-(%g, %g) must be finite", b.minX, b.minY, b.maxX, b.maxY)
+	}
+
+	// The range is worked out in floating point, so that it is checked
+	// before it is turned into tile indices
+	first := func(v, o float64) float64 { return math.Floor((v - o) / t.Size) }
+	last := func(v, o float64) float64 { return math.Ceil((v-o)/t.Size) - 1 }
+	x0, y0 := first(b.minX, t.Origin[0]), first(b.minY, t.Origin[1])
+	x1, y1 := math.Max(x0, last(b.maxX, t.Origin[0])), math.Max(y0, last(b.maxY, t.Origin[1]))
+	if n := (x1 - x0 + 1) * (y1 - y0 + 1); !(n <= maxTiles) {
+		return lo, hi, fmt.Errorf("bounds (%g, %g)-(%g, %g) reach into %g tiles, more than %d", b.minX, b.minY, b.maxX, b.maxY, n, maxTiles)
+	}
+	if math.Max(math.Abs(x0), math.Abs(x1)) > math.MaxInt32 || math.Max(math.Abs(y0), math.Abs(y1)) > math.MaxInt32 {
+		return lo, hi, fmt.Errorf("bounds (%g, %g)-(%g, %g) lie too far from the tile origin", b.minX, b.minY, b.maxX, b.maxY)
+	}
+	return TileIndex{int(x0), int(y0)}, TileIndex{int(x1), int(y1)}, nil
+}
+
+// TilePolygon returns the part of poly in every tile it covers, as ClipRect
+// would for that tile. Tiles that poly only touches are left out. It fails
+// when poly reaches into more than about a million tiles.
+func (t Tiler) TilePolygon(poly Polygon) (map[TileIndex]Polygons, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	if err := validatePolygon("polygon", poly); err != nil {
+		return nil, err
+	}
+
+	tol := scaledTolerance(Polygons{poly})
+	out := make(map[TileIndex]Polygons)
+	lo, hi, err := t.tiles(polygonBounds(poly))
+	if err != nil {
+		return nil, fmt.Errorf("polygon: %w", err)
+	}
+	for y := lo.Y; y <= hi.Y; y++ {
+		for x := lo.X; x <= hi.X; x++ {
+			i := TileIndex{x, y}
+			rings, err := newRectClipper(t.bounds(i), nil, tol).clipRings(poly)
+			if err != nil {
+				return nil, err
+			}
+			if len(rings) > 0 {
+				out[i] = rings
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// meshTileBuilder collects the clipped faces of one tile
+type meshTileBuilder struct {
+	clipper     *rectClipper
+	tile        MeshTile
+	vertexIndex map[Coord]int
+}
+
+func (b *meshTileBuilder) addVertex(v Coord) int {
+	if idx, ok := b.vertexIndex[v]; ok {
+		return idx
+	}
+	idx := len(b.tile.Vertices)
+	b.tile.Vertices = append(b.tile.Vertices, v)
+	b.vertexIndex[v] = idx
+	return idx
+}
+
+// TileMesh cuts every face of a mesh along the tile borders and returns the
+// mesh of every tile it covers, as ClipMeshRect would for that tile. Each
+// face is only clipped against the tiles its bounding box reaches into, and
+// faces without area are dropped. Like TilePolygon, it fails when a face
+// reaches into too many tiles.
+func (t Tiler) TileMesh(vertices []Coord, faces [][3]int) (map[TileIndex]MeshTile, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	// One tolerance for all tiles, so that the tiles agree along their
+	// shared borders
+	tol := scaledTolerance(Polygons{vertices})
+	builders := make(map[TileIndex]*meshTileBuilder)
+
+	for fi, face := range faces {
+		if err := validateFace(vertices, fi, face); err != nil {
+			return nil, err
+		}
+		poly := Polygon{vertices[face[0]], vertices[face[1]], vertices[face[2]]}
+		if tol.negligible(poly) {
+			// Sliver faces cover nothing, as in ClipMesh, however many
+			// tiles they run through
+			continue
+		}
+		lo, hi, err := t.tiles(polygonBounds(poly))
+		if err != nil {
+			return nil, fmt.Errorf("face %d: %w", fi, err)
+		}
+		for y := lo.Y; y <= hi.Y; y++ {
+			for x := lo.X; x <= hi.X; x++ {
+				i := TileIndex{x, y}
+				b, ok := builders[i]
+				if !ok {
+					b = &meshTileBuilder{
+						clipper:     newRectClipper(t.bounds(i), nil, tol),
+						vertexIndex: make(map[Coord]int),
+					}
+					builders[i] = b
+				}
+
+				piece := b.clipper.clip(poly)
+				for k := 1; k+1 < len(piece); k++ {
+					b.tile.Faces = append(b.tile.Faces, [3]int{
+						b.addVertex(piece[0]),
+						b.addVertex(piece[k]),
+						b.addVertex(piece[k+1]),
+					})
+				}
+			}
+		}
+	}
+
+	out := make(map[TileIndex]MeshTile, len(builders))
+	for i, b := range builders {
+		if len(b.tile.Faces) > 0 {
+			out[i] = b.tile
+		}
+	}
+	return out, nil
+}
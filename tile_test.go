@@ -0,0 +1,184 @@
+package clippoly
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func withinBounds(v Coord, b bounds) bool {
+	const slack = 1e-9
+	return v[0] >= b.minX-slack && v[0] <= b.maxX+slack && v[1] >= b.minY-slack && v[1] <= b.maxY+slack
+}
+
+func TestTilePolygon(t *testing.T) {
+	u := Polygon{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}}
+
+	tests := []struct {
+		name   string
+		tiler  Tiler
+		poly   Polygon
+		tiles  int
+		center TileIndex
+		area   float64
+	}{
+		{name: "straddling", tiler: Tiler{Size: 1}, poly: square(0.5, 0.5, 2), tiles: 9, center: TileIndex{1, 1}, area: 1},
+		{name: "aligned", tiler: Tiler{Size: 1}, poly: square(0, 0, 1), tiles: 1, center: TileIndex{0, 0}, area: 1},
+		{name: "negative", tiler: Tiler{Origin: Coord{0.5, 0.5}, Size: 2}, poly: square(-1, -1, 1), tiles: 1, center: TileIndex{-1, -1}, area: 1},
+		{name: "concave", tiler: Tiler{Origin: Coord{-0.5, -0.5}, Size: 2}, poly: u, tiles: 4, center: TileIndex{1, 1}, area: 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tiler.TilePolygon(tt.poly)
+			if err != nil {
+				t.Fatalf("tile: %v", err)
+			}
+			if len(got) != tt.tiles {
+				t.Fatalf("got %d tiles, want %d: %v", len(got), tt.tiles, got)
+			}
+			if a := totalArea(got[tt.center]); math.Abs(a-tt.area) > 1e-9 {
+				t.Fatalf("tile %v area = %.3f, want %.3f", tt.center, a, tt.area)
+			}
+
+			var sum float64
+			for i, rings := range got {
+				sum += totalArea(rings)
+				for _, r := range rings {
+					for _, v := range r {
+						if !withinBounds(v, tt.tiler.bounds(i)) {
+							t.Fatalf("vertex %v of tile %v lies outside it", v, i)
+						}
+					}
+				}
+			}
+			if math.Abs(sum-math.Abs(signedArea(tt.poly))) > 1e-9 {
+				t.Fatalf("tiles add up to %.3f, want %.3f", sum, math.Abs(signedArea(tt.poly)))
+			}
+		})
+	}
+}
+
+func TestTileMesh(t *testing.T) {
+	// a 4x4 grid of quads on the plane z = x + 2y
+	var vertices []Coord
+	for y := 0; y <= 4; y++ {
+		for x := 0; x <= 4; x++ {
+			vertices = append(vertices, Coord{float64(x), float64(y), float64(x + 2*y)})
+		}
+	}
+	var faces [][3]int
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			i := y*5 + x
+			faces = append(faces, [3]int{i, i + 1, i + 6}, [3]int{i, i + 6, i + 5})
+		}
+	}
+
+	tiler := Tiler{Origin: Coord{0.5, 0.5}, Size: 1.5}
+	tiles, err := tiler.TileMesh(vertices, faces)
+	if err != nil {
+		t.Fatalf("tile: %v", err)
+	}
+	if len(tiles) != 16 {
+		t.Fatalf("got %d tiles, want 16", len(tiles))
+	}
+
+	var sum float64
+	for i, tile := range tiles {
+		for _, f := range tile.Faces {
+			tri := Polygon{tile.Vertices[f[0]], tile.Vertices[f[1]], tile.Vertices[f[2]]}
+			if signedArea(tri) <= 0 {
+				t.Fatalf("tile %v face %v lost the winding of the mesh", i, tri)
+			}
+			sum += signedArea(tri)
+			for _, v := range tri {
+				if !withinBounds(v, tiler.bounds(i)) {
+					t.Fatalf("vertex %v of tile %v lies outside it", v, i)
+				}
+				if math.Abs(v[2]-v[0]-2*v[1]) > 1e-9 {
+					t.Fatalf("vertex %v of tile %v is off the mesh", v, i)
+				}
+			}
+		}
+	}
+	if math.Abs(sum-16) > 1e-9 {
+		t.Fatalf("tiles add up to %.3f, want 16", sum)
+	}
+}
+
+func TestTilerInvalidSize(t *testing.T) {
+	for _, size := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if _, err := (Tiler{Size: size}).TilePolygon(square(0, 0, 1)); err == nil {
+			t.Fatalf("expected an error for tile size %g", size)
+		}
+	}
+}
+
+func TestTilerLimits(t *testing.T) {
+	tiler := Tiler{Size: 1}
+
+	tests := []struct {
+		name string
+		err  func() error
+	}{
+		{name: "too_many_tiles", err: func() error {
+			_, err := tiler.TilePolygon(square(0, 0, 1e4))
+			return err
+		}},
+		{name: "nan_vertex", err: func() error {
+			_, err := tiler.TilePolygon(Polygon{{0, 0}, {1, 0}, {math.NaN(), 1}})
+			return err
+		}},
+		{name: "infinite_origin", err: func() error {
+			_, err := (Tiler{Origin: Coord{math.Inf(-1), 0}, Size: 1}).TilePolygon(square(0, 0, 1))
+			return err
+		}},
+		{name: "far_from_origin", err: func() error {
+			_, err := tiler.TilePolygon(square(1e300, 0, 1e290))
+			return err
+		}},
+		{name: "face_too_many_tiles", err: func() error {
+			_, err := tiler.TileMesh([]Coord{{0, 0}, {1e4, 0}, {0, 1e4}}, [][3]int{{0, 1, 2}})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.err(); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestTileMeshFaces(t *testing.T) {
+	tiler := Tiler{Size: 2}
+	vertices := []Coord{{0, 0}, {4, 0}, {4, 4}, {1, 1 + 1e-12}, {1e4, 1e4}}
+
+	// the sliver runs along the diagonal through far more tiles than a face
+	// may reach into, but covers nothing, so it adds no faces
+	want, err := tiler.TileMesh(vertices, [][3]int{{0, 1, 2}})
+	if err != nil {
+		t.Fatalf("tile: %v", err)
+	}
+	got, err := tiler.TileMesh(vertices, [][3]int{{0, 1, 2}, {0, 4, 3}})
+	if err != nil {
+		t.Fatalf("tile: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tiles, want %d", len(got), len(want))
+	}
+	for i, tile := range got {
+		if len(tile.Faces) != len(want[i].Faces) {
+			t.Fatalf("tile %v has %d faces, want %d", i, len(tile.Faces), len(want[i].Faces))
+		}
+	}
+
+	_, err = tiler.TileMesh(vertices, [][3]int{{0, 1, 2}, {0, 2, 5}})
+	var index *FaceIndexError
+	if !errors.As(err, &index) || index.Face != 1 {
+		t.Fatalf("expected a FaceIndexError for face 1, got %v", err)
+	}
+}
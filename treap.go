@@ -0,0 +1,107 @@
+package clippoly
+
+// treap is a binary tree kept balanced by random priorities. Its nodes are
+// only placed by split and merge, so it holds its values in whatever order
+// the caller keeps: intervalTree orders them by key, while the sweep status
+// of monotoneSweep orders them by where the vertex being swept lies. update,
+// if set, recomputes what a node keeps about its subtree once its children
+// change.
+type treap[T any] struct {
+	root   *treapNode[T]
+	seed   uint64
+	update func(n *treapNode[T])
+}
+
+// treapNode is a node of a treap
+type treapNode[T any] struct {
+	value       T
+	prio        uint64
+	left, right *treapNode[T]
+}
+
+// newNode returns a node holding v, ready to be merged into the tree
+func (t *treap[T]) newNode(v T) *treapNode[T] {
+	// xorshift keeps the priorities, and so the tree, repeatable
+	if t.seed == 0 {
+		t.seed = 0x9e3779b97f4a7c15
+	}
+	t.seed ^= t.seed << 13
+	t.seed ^= t.seed >> 7
+	t.seed ^= t.seed << 17
+	n := &treapNode[T]{value: v, prio: t.seed}
+	t.fix(n)
+	return n
+}
+
+func (t *treap[T]) fix(n *treapNode[T]) {
+	if t.update != nil {
+		t.update(n)
+	}
+}
+
+// split splits n into the leading nodes whose values in holds for and the
+// rest. in must hold for a prefix of the values.
+func (t *treap[T]) split(n *treapNode[T], in func(v T) bool) (*treapNode[T], *treapNode[T]) {
+	if n == nil {
+		return nil, nil
+	}
+	if in(n.value) {
+		l, r := t.split(n.right, in)
+		n.right = l
+		t.fix(n)
+		return n, r
+	}
+	l, r := t.split(n.left, in)
+	n.left = r
+	t.fix(n)
+	return l, n
+}
+
+// merge joins two trees, the values of a coming first
+func (t *treap[T]) merge(a, b *treapNode[T]) *treapNode[T] {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.prio > b.prio:
+		a.right = t.merge(a.right, b)
+		t.fix(a)
+		return a
+	}
+	b.left = t.merge(a, b.left)
+	t.fix(b)
+	return b
+}
+
+// each calls fn for every value in order
+func (n *treapNode[T]) each(fn func(v T)) {
+	if n == nil {
+		return
+	}
+	n.left.each(fn)
+	fn(n.value)
+	n.right.each(fn)
+}
+
+// first returns the node of the first value, or nil when there is none
+func (n *treapNode[T]) first() *treapNode[T] {
+	if n == nil {
+		return nil
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+// last returns the node of the last value, or nil when there is none
+func (n *treapNode[T]) last() *treapNode[T] {
+	if n == nil {
+		return nil
+	}
+	for n.right != nil {
+		n = n.right
+	}
+	return n
+}
@@ -0,0 +1,382 @@
+package clippoly
+
+import "sort"
+
+// triangulateRings triangulates the region left of every ring edge, such as
+// a counter-clockwise exterior with clockwise holes. Rings may touch each
+// other in vertices, and vertices may lie on the edges of other rings within
+// tolerance, but edges must not cross. A plane sweep cuts the region into
+// pieces that are monotone in y, which are then triangulated in linear time,
+// so n vertices take O(n log n). The triangles run counter-clockwise. It
+// fails when the rings do not bound a region this way, for example when a
+// hole lies outside the exterior.
+func (tol tolerance) triangulateRings(rings Polygons) (Polygons, error) {
+	m := newMonotoneSweep(rings, tol)
+	if err := m.sweep(); err != nil {
+		return nil, err
+	}
+
+	faces, err := m.faces()
+	if err != nil {
+		return nil, err
+	}
+	var triangles Polygons
+	for _, f := range faces {
+		triangles = m.triangulateMonotone(f, triangles)
+	}
+	return triangles, nil
+}
+
+// monotoneSweep cuts a region into y-monotone pieces. Points are swept top to
+// bottom, and points level with each other left to right, which is the order
+// of a slightly rotated plane in which no edge is horizontal.
+type monotoneSweep struct {
+	tol    tolerance
+	coords []Coord
+	// order lists the vertices in sweep order, and downs the edges running
+	// down from every vertex
+	order []int
+	downs [][]int
+	edges []monotoneEdge
+	// diagonals joins vertices through the region
+	diagonals [][2]int
+	// status has no keys: edges are placed by where the vertex being swept
+	// lies relative to them
+	status treap[int]
+}
+
+// monotoneEdge is a ring edge between the vertices top and bottom
+type monotoneEdge struct {
+	top, bottom int
+	// down is set when the ring runs from top to bottom, which puts the
+	// region on the right of the edge
+	down bool
+	// helper is the lowest vertex seen so far that looks left onto the
+	// edge across the gap right of it. pending is set when the helper has
+	// no edge running down into that gap, so that it still needs a
+	// diagonal to a vertex below.
+	helper  int
+	pending bool
+}
+
+// above orders points for the sweep
+func above(p, q Coord) bool {
+	return p[1] > q[1] || (p[1] == q[1] && p[0] < q[0])
+}
+
+func newMonotoneSweep(rings Polygons, tol tolerance) *monotoneSweep {
+	m := &monotoneSweep{tol: tol}
+
+	// Rings touching in a vertex share it, and edges running both ways
+	// between the same vertices cancel
+	index := make(map[[2]float64]int)
+	vertex := func(c Coord) int {
+		k := [2]float64{c[0], c[1]}
+		i, ok := index[k]
+		if !ok {
+			i = len(m.coords)
+			index[k] = i
+			m.coords = append(m.coords, c)
+		}
+		return i
+	}
+	type link struct{ a, b int }
+	net := make(map[link]int)
+	var links []link
+	for _, ring := range rings {
+		for i := range ring {
+			a, b := vertex(ring[i]), vertex(ring[(i+1)%len(ring)])
+			if a == b {
+				continue
+			}
+			l, w := link{a, b}, 1
+			if a > b {
+				l, w = link{b, a}, -1
+			}
+			if _, ok := net[l]; !ok {
+				links = append(links, l)
+			}
+			net[l] += w
+		}
+	}
+
+	m.downs = make([][]int, len(m.coords))
+	for _, l := range links {
+		if net[l] == 0 {
+			continue
+		}
+		from, to := l.a, l.b
+		if net[l] < 0 {
+			from, to = to, from
+		}
+		m.addEdge(from, to)
+	}
+
+	m.order = make([]int, len(m.coords))
+	for i := range m.order {
+		m.order[i] = i
+	}
+	sort.Slice(m.order, func(i, j int) bool { return above(m.coords[m.order[i]], m.coords[m.order[j]]) })
+	return m
+}
+
+// addEdge adds the ring edge from one vertex to another
+func (m *monotoneSweep) addEdge(from, to int) {
+	e := monotoneEdge{top: from, bottom: to, down: true}
+	if above(m.coords[to], m.coords[from]) {
+		e = monotoneEdge{top: to, bottom: from}
+	}
+	m.edges = append(m.edges, e)
+	m.downs[e.top] = append(m.downs[e.top], len(m.edges)-1)
+}
+
+// sweep finds the diagonals that make every piece of the region monotone,
+// following de Berg et al., "Computational Geometry", chapter 3, for any
+// number of edges at a vertex. The status holds the edges the sweep line
+// cuts from left to right. Every gap between them is inside or outside the
+// region, and a vertex inside a gap, or one closing a gap whose helper is
+// pending, is joined to the helper of that gap.
+func (m *monotoneSweep) sweep() error {
+	for _, v := range m.order {
+		// Edges ending at v pass through it, and so do edges that v lies on
+		through := func(e int) bool {
+			a, b, p := m.coords[m.edges[e].bottom], m.coords[m.edges[e].top], m.coords[v]
+			return orient(a, b, p) == 0 || m.tol.pointOnEdge(p[0], p[1], a[0], a[1], b[0], b[1])
+		}
+		left, rest := m.status.split(m.status.root, func(e int) bool {
+			a, b := m.coords[m.edges[e].bottom], m.coords[m.edges[e].top]
+			return orient(a, b, m.coords[v]) < 0 && !through(e)
+		})
+		ending, right := m.status.split(rest, through)
+
+		var ups []int
+		ending.each(func(e int) { ups = append(ups, e) })
+		for _, e := range ups {
+			if b := m.edges[e].bottom; b != v {
+				// v lies on the edge, which ends at v from now on
+				m.edges[e].bottom = v
+				m.edges = append(m.edges, monotoneEdge{top: v, bottom: b, down: m.edges[e].down})
+				m.downs[v] = append(m.downs[v], len(m.edges)-1)
+			}
+		}
+		downs := m.downs[v]
+		if len(ups) == 0 && len(downs) == 0 {
+			// All edges at v cancelled
+			m.status.root = m.status.merge(left, right)
+			continue
+		}
+		c := m.coords[v]
+		sort.Slice(downs, func(i, j int) bool {
+			return orient(c, m.coords[m.edges[downs[i]].bottom], m.coords[m.edges[downs[j]].bottom]) > 0
+		})
+
+		eL, eR := statusEdge(left.last()), statusEdge(right.first())
+		inLeft := eL >= 0 && m.edges[eL].down
+		inRight := eR >= 0 && !m.edges[eR].down
+		if !m.alternates(ups, inLeft, inRight) || !m.alternates(downs, inLeft, inRight) {
+			return &RegionError{At: c}
+		}
+
+		if len(ups) == 0 {
+			if inLeft {
+				m.diagonals = append(m.diagonals, [2]int{v, m.edges[eL].helper})
+			}
+		} else {
+			gap := eL
+			for _, e := range append(ups, -1) {
+				if gap >= 0 && m.edges[gap].down && m.edges[gap].pending {
+					m.diagonals = append(m.diagonals, [2]int{v, m.edges[gap].helper})
+				}
+				gap = e
+			}
+		}
+
+		if eL >= 0 {
+			m.edges[eL].helper, m.edges[eL].pending = v, len(downs) == 0
+		}
+		below := left
+		for _, e := range downs {
+			m.edges[e].helper, m.edges[e].pending = v, false
+			below = m.status.merge(below, m.status.newNode(e))
+		}
+		m.status.root = m.status.merge(below, right)
+	}
+	return nil
+}
+
+// alternates checks that the edges, ordered left to right at one vertex,
+// separate the inside of the region from the outside, given whether the
+// gaps left and right of the vertex are inside
+func (m *monotoneSweep) alternates(edges []int, inLeft, inRight bool) bool {
+	in := inLeft
+	for _, e := range edges {
+		// The region lies left of an edge running up
+		if m.edges[e].down == in {
+			return false
+		}
+		in = m.edges[e].down
+	}
+	return in == inRight
+}
+
+// halfEdge is one side of a ring edge or diagonal, running from one vertex
+// to another with the region on its left when inside is set
+type halfEdge struct {
+	from, to int
+	inside   bool
+	twin     *halfEdge
+	next     *halfEdge
+	seen     bool
+}
+
+// faces returns the vertices of every monotone piece counter-clockwise
+func (m *monotoneSweep) faces() ([][]int, error) {
+	out := make([][]*halfEdge, len(m.coords))
+	add := func(a, b int, inA, inB bool) {
+		h := &halfEdge{from: a, to: b, inside: inA}
+		t := &halfEdge{from: b, to: a, inside: inB, twin: h}
+		h.twin = t
+		out[a] = append(out[a], h)
+		out[b] = append(out[b], t)
+	}
+	for _, e := range m.edges {
+		if e.down {
+			add(e.top, e.bottom, true, false)
+		} else {
+			add(e.bottom, e.top, true, false)
+		}
+	}
+	for _, d := range m.diagonals {
+		add(d[0], d[1], true, true)
+	}
+
+	// Coming into a vertex, the piece continues along the first edge
+	// clockwise from the one it came in on
+	for v, hs := range out {
+		c := m.coords[v]
+		half := func(h *halfEdge) int {
+			d := m.coords[h.to]
+			if d[1] > c[1] || (d[1] == c[1] && d[0] > c[0]) {
+				return 0
+			}
+			return 1
+		}
+		sort.Slice(hs, func(i, j int) bool {
+			if hi, hj := half(hs[i]), half(hs[j]); hi != hj {
+				return hi < hj
+			}
+			return orient(c, m.coords[hs[i].to], m.coords[hs[j].to]) > 0
+		})
+		for i, h := range hs {
+			h.twin.next = hs[(i+len(hs)-1)%len(hs)]
+		}
+	}
+
+	var faces [][]int
+	for _, hs := range out {
+		for _, h := range hs {
+			if !h.inside || h.seen {
+				continue
+			}
+			var face []int
+			for g := h; !g.seen; g = g.next {
+				if !g.inside {
+					return nil, &RegionError{At: m.coords[g.from]}
+				}
+				g.seen = true
+				face = append(face, g.from)
+			}
+			faces = append(faces, face)
+		}
+	}
+	return faces, nil
+}
+
+// triangulateMonotone appends the triangles of a monotone piece, whose
+// vertices run counter-clockwise, to triangles. The two chains from its top
+// to its bottom are merged in sweep order, and every vertex cuts off the
+// triangles it can see among the vertices passed so far.
+func (m *monotoneSweep) triangulateMonotone(face []int, triangles Polygons) Polygons {
+	n := len(face)
+	if n < 3 {
+		return triangles
+	}
+	top, bottom := 0, 0
+	for i, v := range face {
+		if above(m.coords[v], m.coords[face[top]]) {
+			top = i
+		}
+		if above(m.coords[face[bottom]], m.coords[v]) {
+			bottom = i
+		}
+	}
+
+	// Counter-clockwise from the top runs down the left chain
+	type point struct {
+		v    int
+		left bool
+	}
+	sorted := make([]point, 0, n)
+	l, r := top, top
+	sorted = append(sorted, point{face[top], true})
+	for len(sorted) < n {
+		nl, nr := (l+1)%n, (r+n-1)%n
+		if l != bottom && (nr == bottom || above(m.coords[face[nl]], m.coords[face[nr]])) {
+			l = nl
+			sorted = append(sorted, point{face[l], l != bottom})
+		} else {
+			r = nr
+			sorted = append(sorted, point{face[r], false})
+		}
+	}
+
+	emit := func(a, b, c int) {
+		pa, pb, pc := m.coords[a], m.coords[b], m.coords[c]
+		switch o := orient(pa, pb, pc); {
+		case o > 0:
+			triangles = append(triangles, Polygon{pa, pb, pc})
+		case o < 0:
+			triangles = append(triangles, Polygon{pa, pc, pb})
+		}
+	}
+
+	stack := []point{sorted[0], sorted[1]}
+	for j := 2; j < n-1; j++ {
+		u := sorted[j]
+		if u.left != stack[len(stack)-1].left {
+			for k := len(stack) - 1; k > 0; k-- {
+				emit(u.v, stack[k].v, stack[k-1].v)
+			}
+			stack = append(stack[:0], sorted[j-1], u)
+			continue
+		}
+		last := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for len(stack) > 0 {
+			// The diagonal to the next vertex on the stack runs inside
+			// when the last one bulges out of the piece
+			o := orient(m.coords[stack[len(stack)-1].v], m.coords[last.v], m.coords[u.v])
+			if (u.left && o <= 0) || (!u.left && o >= 0) {
+				break
+			}
+			emit(u.v, last.v, stack[len(stack)-1].v)
+			last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, last, u)
+	}
+	u := sorted[n-1]
+	for k := len(stack) - 1; k > 0; k-- {
+		emit(u.v, stack[k].v, stack[k-1].v)
+	}
+	return triangles
+}
+
+// statusEdge returns the edge held by a node of the sweep status, or -1 for
+// no node
+func statusEdge(n *treapNode[int]) int {
+	if n == nil {
+		return -1
+	}
+	return n.value
+}
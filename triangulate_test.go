@@ -0,0 +1,103 @@
+package clippoly
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// checkTriangulation checks that tris are counter-clockwise, lie inside r
+// and cover its area
+func checkTriangulation(t *testing.T, r Region, tris Polygons) {
+	t.Helper()
+	want := regionArea(r)
+	var area float64
+	for _, tri := range tris {
+		if orient(tri[0], tri[1], tri[2]) <= 0 {
+			t.Fatalf("triangle %v of %v is not counter-clockwise", tri, r)
+		}
+		area += signedArea(tri)
+		if signedArea(tri) < 1e-9*want {
+			// The centroid of a sliver may land on either side of an edge
+			continue
+		}
+		c := Coord{(tri[0][0] + tri[1][0] + tri[2][0]) / 3, (tri[0][1] + tri[1][1] + tri[2][1]) / 3}
+		inside := isInsidePolygon(c, r.Exterior, NonZero)
+		for _, h := range r.Holes {
+			inside = inside && !isInsidePolygon(c, h, NonZero)
+		}
+		if !inside {
+			t.Fatalf("triangle %v lies outside %v", tri, r)
+		}
+	}
+	if math.Abs(area-want) > 1e-9*want {
+		t.Fatalf("triangles of %v cover %g, want %g", r, area, want)
+	}
+}
+
+func TestTriangulateRegionShapes(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+
+	tests := []struct {
+		name   string
+		region Region
+	}{
+		{name: "comb", region: Region{Exterior: comb(50, 10)}},
+		{name: "wobbly", region: Region{Exterior: wobblyCircle(rng, 0, 0, 10, 2000)}},
+		{
+			name: "holes_touching_each_other",
+			region: Region{
+				Exterior: square(0, 0, 10),
+				Holes:    Polygons{{{2, 2}, {5, 5}, {2, 5}}, {{5, 5}, {8, 8}, {8, 5}}},
+			},
+		},
+		{
+			name: "hole_touching_exterior_vertex",
+			region: Region{
+				Exterior: Polygon{{0, 0}, {10, 0}, {10, 10}, {5, 5}, {0, 10}},
+				Holes:    Polygons{{{5, 5}, {6, 2}, {4, 2}}},
+			},
+		},
+		{
+			name: "level_vertices",
+			region: Region{
+				Exterior: Polygon{{0, 0}, {1, 1}, {2, 0}, {3, 1}, {4, 0}, {4, 3}, {3, 2}, {2, 3}, {1, 2}, {0, 3}},
+				Holes:    Polygons{{{1, 1.5}, {2, 1.5}, {3, 1.5}, {2, 1}}},
+			},
+		},
+		{name: "collinear_vertices", region: Region{Exterior: Polygon{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}, {1, 1}, {0, 2}, {0, 1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tris, err := TriangulateRegion(tt.region)
+			if err != nil {
+				t.Fatalf("triangulate: %v", err)
+			}
+			checkTriangulation(t, orientRegion(tt.region), tris)
+		})
+	}
+}
+
+func TestTriangulateRegionRandom(t *testing.T) {
+	// The regions of self-intersecting rings, with holes that touch each
+	// other and the exterior where the rings cross
+	rng := rand.New(rand.NewSource(8))
+	for i := 0; i < 300; i++ {
+		poly := make(Polygon, 3+rng.Intn(20))
+		for k := range poly {
+			poly[k] = Coord{20 * rng.Float64(), 20 * rng.Float64()}
+		}
+		rings, err := SimplifyPolygon(poly, EvenOdd)
+		if err != nil {
+			t.Fatalf("simplify %v: %v", poly, err)
+		}
+		for _, r := range Regions(rings) {
+			tris, err := TriangulateRegion(r)
+			if err != nil {
+				t.Fatalf("triangulate %v: %v", r, err)
+			}
+			checkTriangulation(t, r, tris)
+		}
+	}
+}